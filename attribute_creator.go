@@ -1,15 +1,460 @@
 package marshalddb
 
 import (
+	"encoding"
 	"encoding/json"
-	"math"
 	"reflect"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
+func (e *Encoder) createStructAttributes(from reflect.Value, to map[string]*dynamodb.AttributeValue) error {
+
+	var errs Errors
+	for _, fld := range e.fields.cachedTypeFields(from.Type()).list {
+
+		f := fieldByIndex(from, fld.index)
+		if !f.IsValid() {
+			continue
+		}
+
+		// funcs given to EncodeTypeWith are only looked up by createAttribute
+		encode := fld.encode
+		if len(e.encoders) != 0 {
+			encode = (*Encoder).createAttribute
+		}
+
+		tag := fld.fieldTag
+		fi, err := e.createField(f, tag, encode)
+		if err != nil {
+			err = encodeError(err, tag.name, f.Type())
+			if !e.collectErrors {
+				return err
+			}
+			errs = errs.add(err)
+		}
+
+		if fi != nil {
+			to[tag.name] = fi
+		}
+	}
+
+	return errs.err()
+}
+
+// createField converts the value of a struct field into its AttributeValue
+// with encode, or returns nil if the field is omitted
+func (e *Encoder) createField(f reflect.Value, tag fieldTag, encode encoderFunc) (*dynamodb.AttributeValue, error) {
+
+	if (tag.omitEmpty || e.omitEmpty) && isEmptyValue(f) {
+		return nil, nil
+	}
+	if e.omitEmpty && f.IsZero() {
+		return nil, nil
+	}
+
+	if f.Kind() == reflect.Ptr {
+		f = f.Elem()
+	}
+
+	var (
+		fi  *dynamodb.AttributeValue
+		err error
+	)
+
+	switch {
+
+	case !f.IsValid():
+		// a nil pointer
+
+	case tag.json && (f.Kind() == reflect.Struct || f.Kind() == reflect.Map):
+		fi, err = createSJSON(f)
+
+	default:
+		fi, err = encode(e, f, tag)
+	}
+	if err != nil {
+		return fi, err
+	}
+
+	if fi == nil && (tag.nullable || e.nullEmpty) {
+		fi = &dynamodb.AttributeValue{
+			NULL: aws.Bool(true),
+		}
+	}
+
+	return fi, nil
+}
+
+// createAttribute converts a single value into its AttributeValue, using
+// the options of the struct field tag it was read from.
+// A nil AttributeValue is returned for values that DynamoDB does not
+// allow to be stored, such as empty strings and sets.
+func (e *Encoder) createAttribute(f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	// numbers may be stored as strings whatever their tag
+	numTag := tag
+	numTag.asString = tag.asString || e.numbers == TypeString
+
+	for {
+
+		if fn, ok := e.encoders[f.Type()]; ok && f.CanInterface() {
+			return fn(f.Interface())
+		}
+
+		switch f.Type() {
+
+		case timeType:
+			return createTime(f.Interface().(time.Time), tag)
+
+		case durationType:
+			return createDuration(time.Duration(f.Int()), numTag), nil
+
+		case numberType:
+			return createNumber(Number(f.String()), numTag)
+
+		case bigIntType, bigFloatType, bigRatType:
+			return createBig(f, numTag)
+		}
+
+		// the big types are numbers, rather than the text they marshal to
+		if fi, ok, err := createMarshaled(f, e.configured); ok && !isBig(f.Type()) {
+			return fi, err
+		}
+
+		if f.Kind() != reflect.Ptr && f.Kind() != reflect.Interface {
+			break
+		}
+		if f.IsNil() {
+			return nil, nil
+		}
+		f = f.Elem()
+	}
+
+	return e.createValue(f, tag)
+}
+
+// createValue converts a value by its kind, once any pointers have been
+// followed and none of the types converted specially apply
+func (e *Encoder) createValue(f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	switch f.Kind() {
+
+	case reflect.String:
+		return e.createString(f, tag)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return e.createNumeric(f, tag)
+
+	case reflect.Bool:
+		return e.createBool(f, tag)
+
+	case reflect.Slice, reflect.Array:
+
+		// a nil slice holds no value, while an empty one is kept as an
+		// empty L. DynamoDB does not allow empty sets, so those are
+		// omitted either way.
+		if f.Kind() == reflect.Slice && f.IsNil() {
+			return nil, nil
+		}
+
+		et := f.Type().Elem()
+		switch {
+
+		case isMarshaled(et):
+			return e.createL(f, tag)
+
+		case tag.asSet && et.Kind() != reflect.Uint8 && isSetElem(et):
+			return createSet(f, tag.binary)
+
+		case tag.binary:
+			if f.Len() == 0 {
+				return nil, nil
+			}
+			return createBinary(f)
+
+		case tag.asList || (e.slicesAsLists && et.Kind() != reflect.Uint8):
+			return e.createL(f, tag)
+
+		case f.Len() == 0 && (isSetElem(et) || et.Kind() == reflect.Uint8):
+			return nil, nil
+
+		case et.Kind() == reflect.Uint8:
+			return createBinary(f)
+
+		case et.Kind() == reflect.String && et != numberType:
+			return createSS(f), nil
+
+		case isNumericKind(et.Kind()) || et == numberType:
+			return createNS(f)
+
+		case (et.Kind() == reflect.Slice || et.Kind() == reflect.Array) && et.Elem().Kind() == reflect.Uint8:
+			return createBS(f), nil
+
+		case tag.asSet:
+			// only strings, numbers and binary values can be held by a set
+			return nil, ErrConversionNotSupported
+		}
+
+		switch et.Kind() {
+
+		case reflect.Struct, reflect.Map, reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Array:
+			return e.createL(f, tag)
+
+		default:
+			return nil, ErrConversionNotSupported
+		}
+
+	case reflect.Map:
+		if isMapSet(f.Type(), tag) {
+			return createMapSet(f, tag)
+		}
+		return e.createM(f, tag)
+
+	case reflect.Struct:
+		return e.createM(f, tag)
+
+	default:
+		return nil, ErrConversionNotSupported
+	}
+}
+
+func (e *Encoder) createString(f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	// Dynamo does not allow setting empty strings
+	// http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_PutItem.html
+	if f.String() == "" {
+		return nil, nil
+	}
+	if tag.binary {
+		return &dynamodb.AttributeValue{
+			B: []byte(f.String()),
+		}, nil
+	}
+	return &dynamodb.AttributeValue{
+		S: aws.String(f.String()),
+	}, nil
+}
+
+func (e *Encoder) createNumeric(f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	n, err := formatNumber(f)
+	if err != nil {
+		return nil, err
+	}
+	if tag.asString || e.numbers == TypeString {
+		return &dynamodb.AttributeValue{
+			S: aws.String(n),
+		}, nil
+	}
+	return &dynamodb.AttributeValue{
+		N: aws.String(n),
+	}, nil
+}
+
+func (e *Encoder) createBool(f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	switch {
+
+	case tag.asString || e.bools == TypeString:
+		return &dynamodb.AttributeValue{
+			S: aws.String(strconv.FormatBool(f.Bool())),
+		}, nil
+
+	case e.bools == TypeNumber:
+		n := "0"
+		if f.Bool() {
+			n = "1"
+		}
+		return &dynamodb.AttributeValue{
+			N: aws.String(n),
+		}, nil
+	}
+	return &dynamodb.AttributeValue{
+		BOOL: aws.Bool(f.Bool()),
+	}, nil
+}
+
+// createM converts a struct or a map keyed by strings into an M attribute
+func (e *Encoder) createM(from reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	var errs Errors
+	m := make(map[string]*dynamodb.AttributeValue)
+
+	switch from.Kind() {
+
+	case reflect.Struct:
+		if err := e.createStructAttributes(from, m); err != nil {
+			if !e.collectErrors {
+				return nil, err
+			}
+			errs = errs.add(err)
+		}
+
+	case reflect.Map:
+
+		if from.IsNil() {
+			return nil, nil
+		}
+
+		keys := from.MapKeys()
+		if e.collectErrors {
+			// maps are unordered, so sort the keys to meet errors in the
+			// same order on every run
+			sortMapKeys(keys)
+		}
+
+		for _, k := range keys {
+
+			key, err := mapKeyName(k)
+			if err != nil {
+				err = encodeError(err, "", k.Type())
+				if !e.collectErrors {
+					return nil, err
+				}
+				errs = errs.add(err)
+				continue
+			}
+
+			v := from.MapIndex(k)
+			fi, err := e.createAttribute(v, tag.elem())
+			if err != nil {
+				err = encodeError(err, key, v.Type())
+				if !e.collectErrors {
+					return nil, err
+				}
+				errs = errs.add(err)
+			}
+			if fi != nil {
+				m[key] = fi
+			}
+		}
+
+	default:
+		return nil, ErrConversionNotSupported
+	}
+
+	return &dynamodb.AttributeValue{
+		M: m,
+	}, errs.err()
+}
+
+// createL converts each element of a slice or array into an L attribute.
+// Elements that cannot be stored, such as nil pointers, are kept as NULL
+// so the order and length of the list is preserved.
+func (e *Encoder) createL(from reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	var errs Errors
+	flen := from.Len()
+	dst := make([]*dynamodb.AttributeValue, flen)
+	for i := 0; i < flen; i++ {
+
+		fi, err := e.createAttribute(from.Index(i), tag.elem())
+		if err != nil {
+			err = encodeError(err, indexSegment(i), from.Index(i).Type())
+			if !e.collectErrors {
+				return nil, err
+			}
+			errs = errs.add(err)
+		}
+		if fi == nil {
+			fi = &dynamodb.AttributeValue{
+				NULL: aws.Bool(true),
+			}
+		}
+		dst[i] = fi
+	}
+
+	return &dynamodb.AttributeValue{
+		L: dst,
+	}, errs.err()
+}
+
+// mapKeyName returns the attribute name of a map key, which must either
+// be a string or implement encoding.TextMarshaler
+func mapKeyName(k reflect.Value) (string, error) {
+
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+
+	if tm, ok := valueAs(k, textMarshalerType); ok {
+
+		text, err := tm.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(text), nil
+	}
+
+	return "", ErrConversionNotSupported
+}
+
+// sortMapKeys sorts the keys of a map by their attribute names
+func sortMapKeys(keys []reflect.Value) {
+
+	byName := mapKeysByName{
+		keys:  keys,
+		names: make([]string, len(keys)),
+	}
+	for i, k := range keys {
+		byName.names[i], _ = mapKeyName(k)
+	}
+	sort.Sort(byName)
+}
+
+// mapKeysByName sorts the keys of a map by their attribute names
+type mapKeysByName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (x mapKeysByName) Len() int { return len(x.keys) }
+
+func (x mapKeysByName) Swap(i, j int) {
+	x.keys[i], x.keys[j] = x.keys[j], x.keys[i]
+	x.names[i], x.names[j] = x.names[j], x.names[i]
+}
+
+func (x mapKeysByName) Less(i, j int) bool { return x.names[i] < x.names[j] }
+
+// createBinary converts a byte slice into a B attribute, or a slice of
+// strings or byte slices into a BS attribute
+func createBinary(from reflect.Value) (*dynamodb.AttributeValue, error) {
+
+	et := from.Type().Elem()
+	switch {
+
+	case et.Kind() == reflect.Uint8:
+		return &dynamodb.AttributeValue{
+			B: bytesOf(from),
+		}, nil
+
+	case et.Kind() == reflect.String:
+
+		flen := from.Len()
+		dst := make([][]byte, flen)
+		for i := 0; i < flen; i++ {
+			dst[i] = []byte(from.Index(i).String())
+		}
+
+		return &dynamodb.AttributeValue{
+			BS: dst,
+		}, nil
+
+	case (et.Kind() == reflect.Slice || et.Kind() == reflect.Array) && et.Elem().Kind() == reflect.Uint8:
+		return createBS(from), nil
+	}
+
+	return nil, ErrConversionNotSupported
+}
+
 func createSS(from reflect.Value) *dynamodb.AttributeValue {
 
 	flen := from.Len()
@@ -49,11 +494,11 @@ func createNS(from reflect.Value) (*dynamodb.AttributeValue, error) {
 			dst[i] = aws.String(strconv.FormatUint(e.Uint(), 10))
 
 		case reflect.Float32, reflect.Float64:
-			ff := e.Float()
-			if math.IsInf(ff, 0) || math.IsNaN(ff) {
-				return nil, ErrInvalidFloat
+			n, err := FormatFloat(e.Float(), e.Type().Bits())
+			if err != nil {
+				return nil, err
 			}
-			dst[i] = aws.String(strconv.FormatFloat(ff, 'g', -1, e.Type().Bits()))
+			dst[i] = aws.String(n)
 
 		case reflect.Bool:
 			if e.Bool() {
@@ -61,6 +506,13 @@ func createNS(from reflect.Value) (*dynamodb.AttributeValue, error) {
 			} else {
 				dst[i] = aws.String("0")
 			}
+
+		case reflect.String:
+			// a Number
+			if err := CheckNumber(e.String()); err != nil {
+				return nil, err
+			}
+			dst[i] = aws.String(e.String())
 		}
 	}
 
@@ -73,43 +525,8 @@ func createBS(from reflect.Value) *dynamodb.AttributeValue {
 
 	flen := from.Len()
 	dst := make([][]byte, flen)
-
-	var fk reflect.Kind
-	if flen != 0 && from.Index(0).Len() != 0 {
-		fk = from.Index(0).Index(0).Kind()
-	}
-
 	for i := 0; i < flen; i++ {
-
-		e := from.Index(i)
-		if e.Kind() == reflect.Ptr {
-			e = e.Elem()
-		}
-
-		elen := e.Len()
-		dst[i] = make([]byte, elen)
-		for j := 0; j < elen; j++ {
-
-			switch fk {
-
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				dst[i][j] = uint8(e.Index(j).Int())
-
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-				dst[i][j] = uint8(e.Index(j).Uint())
-
-			case reflect.Float32, reflect.Float64:
-				dst[i][j] = uint8(e.Index(j).Float())
-
-			case reflect.Bool:
-				if e.Index(j).Bool() {
-					dst[i][j] = 1
-				} else {
-					dst[i][j] = 0
-				}
-			}
-		}
-
+		dst[i] = bytesOf(from.Index(i))
 	}
 
 	return &dynamodb.AttributeValue{
@@ -129,23 +546,113 @@ func createSJSON(from reflect.Value) (*dynamodb.AttributeValue, error) {
 	}, nil
 }
 
-func fieldByName(v reflect.Value, name string) reflect.Value {
+// fieldByName finds the field of struct v that is read from the attribute
+// name, allocating any nil embedded structs it is promoted through.
+// A field whose attribute name is an exact match is preferred, followed by
+// a field with that Go name, a field with that alias and finally a field
+// whose attribute name matches without regard to case, as encoding/json does.
+func (c *fieldCache) fieldByName(v reflect.Value, name string) (reflect.Value, field) {
 
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 
 	if v.Kind() != reflect.Struct {
-		return reflect.Value{}
+		return reflect.Value{}, field{}
 	}
 
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
+	f, ok := c.cachedTypeFields(v.Type()).lookup(name)
+	if !ok {
+		return reflect.Value{}, field{}
+	}
 
-		if t.Field(i).Name == name || t.Field(i).Tag.Get("json") == name {
-			return v.Field(i)
-		}
+	return allocFieldByIndex(v, f.index), f
+}
+
+// isSetElem reports whether a slice of t is stored as an SS, NS or BS attribute
+func isSetElem(t reflect.Type) bool {
+
+	switch t.Kind() {
+
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+
+	return t.Kind() == reflect.String || isNumericKind(t.Kind())
+}
+
+func isNumericKind(k reflect.Kind) bool {
+
+	switch k {
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Bool:
+		return true
+	}
+
+	return false
+}
+
+// bytesOf copies a slice or array of bytes
+func bytesOf(from reflect.Value) []byte {
+
+	flen := from.Len()
+	dst := make([]byte, flen)
+	for i := 0; i < flen; i++ {
+		dst[i] = byte(from.Index(i).Uint())
+	}
+
+	return dst
+}
+
+// formatNumber formats an integer or float in the form DynamoDB expects of an N attribute
+func formatNumber(from reflect.Value) (string, error) {
+
+	switch from.Kind() {
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(from.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(from.Uint(), 10), nil
+
+	case reflect.Float32, reflect.Float64:
+		return FormatFloat(from.Float(), from.Type().Bits())
+	}
+
+	return "", ErrConversionNotSupported
+}
+
+// isEmptyValue reports whether v is empty by the same rules as
+// encoding/json's omitempty, which also treats the zero time.Time as empty
+func isEmptyValue(v reflect.Value) bool {
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).IsZero()
+	}
+
+	switch v.Kind() {
+
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+
+	case reflect.Bool:
+		return !v.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
 	}
 
-	return reflect.Value{}
+	return false
 }
@@ -0,0 +1,155 @@
+package marshalddb
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// bigFloatPrec is the precision in bits of a decoded big.Float, which is
+// enough to hold the 38 significant digits of an N attribute
+const bigFloatPrec = 128
+
+// isBig reports whether t is one of the math/big number types, or a
+// pointer to one
+func isBig(t reflect.Type) bool {
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == bigIntType || t == bigFloatType || t == bigRatType
+}
+
+// createBig converts a big.Int, big.Float or big.Rat into an N attribute,
+// or an S attribute if the field is tagged with the string option. A Rat
+// must have a terminating decimal expansion.
+func createBig(f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	if !f.CanAddr() {
+		c := reflect.New(f.Type()).Elem()
+		c.Set(f)
+		f = c
+	}
+
+	var n string
+	switch x := f.Addr().Interface().(type) {
+
+	case *big.Int:
+		n = x.String()
+
+	case *big.Float:
+		if x.IsInf() {
+			return nil, ErrInvalidFloat
+		}
+		n = x.Text('g', -1)
+
+	case *big.Rat:
+		places, ok := decimalPlaces(x.Denom())
+		if !ok {
+			return nil, ErrNumberRange
+		}
+		n = x.FloatString(places)
+	}
+
+	if !inNumberRange(n) {
+		return nil, ErrNumberRange
+	}
+
+	if tag.asString {
+		return &dynamodb.AttributeValue{
+			S: aws.String(n),
+		}, nil
+	}
+	return &dynamodb.AttributeValue{
+		N: aws.String(n),
+	}, nil
+}
+
+// decimalPlaces returns the number of decimal places needed to write a
+// fraction with denominator d exactly, which is only possible if d has no
+// prime factors other than 2 and 5
+func decimalPlaces(d *big.Int) (int, bool) {
+
+	var (
+		twos, fives int
+		m           big.Int
+	)
+
+	d = new(big.Int).Set(d)
+	two, five := big.NewInt(2), big.NewInt(5)
+	for d.Sign() != 0 && m.Mod(d, two).Sign() == 0 {
+		d.Quo(d, two)
+		twos++
+	}
+	for d.Sign() != 0 && m.Mod(d, five).Sign() == 0 {
+		d.Quo(d, five)
+		fives++
+	}
+
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
+
+// setBig sets an N, or numeric S, attribute onto a big.Int, big.Float or
+// big.Rat without losing precision
+func setBig(attr *dynamodb.AttributeValue, toField *reflect.Value) error {
+
+	var s string
+	switch {
+
+	case attr.N != nil:
+		s = *attr.N
+
+	case attr.S != nil:
+		s = *attr.S
+
+	case attr.NULL != nil:
+		toField.Set(reflect.Zero(toField.Type()))
+		return nil
+
+	default:
+		return ErrInvalidConversion
+	}
+
+	if !isValidNumber(s) {
+		return ErrInvalidStringForNumber
+	}
+
+	switch x := toField.Addr().Interface().(type) {
+
+	case *big.Int:
+		n, err := Number(s).BigInt()
+		if err != nil {
+			return err
+		}
+		x.Set(n)
+
+	case *big.Float:
+		if x.Prec() == 0 {
+			x.SetPrec(bigFloatPrec)
+		}
+		if _, ok := x.SetString(s); !ok {
+			return ErrInvalidStringForNumber
+		}
+
+	case *big.Rat:
+		if _, ok := x.SetString(s); !ok {
+			return ErrInvalidStringForNumber
+		}
+	}
+
+	return nil
+}
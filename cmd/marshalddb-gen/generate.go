@@ -0,0 +1,1437 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const marshalddbPath = "github.com/jessejlt/marshalddb"
+
+// kind is how the generated code converts a field
+type kind int
+
+const (
+	// delegated fields are only converted by a marshalddb.Field
+	delegated kind = iota
+	stringKind
+	boolKind
+	intKind
+	uintKind
+	floatKind
+	timeKind
+	durationKind
+	// numberKind fields are a marshalddb.Number
+	numberKind
+	// generatedKind fields are a struct with generated methods
+	generatedKind
+	sliceKind
+	mapKind
+)
+
+// field is a struct field that is read from and written to an attribute
+type field struct {
+	goName string
+	name   string
+	opts   string
+	tagged bool
+	// sel selects the field from v, through any embedded structs
+	sel string
+	// id names the field's marshalddb.Field, which is unique even among
+	// promoted fields
+	id string
+	// index is the sequence of field indexes leading to the field
+	index []int
+	// ptrs are the embedded struct pointers the field is promoted through
+	ptrs []embedded
+	kind kind
+	// bits is the size of a number field, or 0 for an int or uint
+	bits int
+	// conv is the field's named type, which values are converted to
+	// when they are set, or the empty string for a predeclared type
+	conv string
+	// typ is the name of the field's type if it is not delegated, or of
+	// the type a pointer field points to
+	typ string
+	// ptr is set if the field is a pointer to a value of its kind
+	ptr bool
+	// elem is the kind of the elements of a slice or map field
+	elem *field
+	// key is the named type of the keys of a map field, or the empty
+	// string for string keys
+	key string
+}
+
+// embedded is a pointer to an embedded struct that fields are promoted
+// through
+type embedded struct {
+	// sel selects the pointer from v
+	sel string
+	// typ is the type the pointer points to
+	typ string
+	// exported is false if marshalddb cannot allocate the pointer
+	exported bool
+}
+
+// has reports whether the field's tag includes option
+func (f field) has(option string) bool {
+
+	for _, o := range strings.Split(f.opts, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// aliases returns the other attribute names the field is decoded from
+func (f field) aliases() []string {
+
+	var aliases []string
+	for _, o := range strings.Split(f.opts, ",") {
+		if strings.HasPrefix(o, "alias=") {
+			aliases = append(aliases, o[len("alias="):])
+		}
+	}
+	return aliases
+}
+
+// unixTime returns the option storing a time.Time field as a number, or
+// the empty string
+func (f field) unixTime() string {
+
+	for _, o := range []string{"unix", "unixmilli", "unixnano"} {
+		if f.has(o) {
+			return o
+		}
+	}
+	return ""
+}
+
+// generate returns the formatted source of the methods of each named
+// type in the package within dir, ignoring the file out
+func generate(dir string, typeNames []string, out string) ([]byte, error) {
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(out)
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	var pkgName string
+	for name, pkg := range pkgs {
+
+		pkgName = name
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+	// keep the type checker's view of the package stable
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name()
+	})
+
+	// types that fail to resolve are left to marshalddb, so type
+	// errors are not fatal
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(pkgName, fset, files, nil)
+
+	g := &generator{
+		pkg:       pkg,
+		imports:   map[string]bool{"github.com/aws/aws-sdk-go/service/dynamodb": true, marshalddbPath: true},
+		generated: make(map[string]bool, len(typeNames)),
+	}
+	// fields of any of the types call its methods, even if they are
+	// generated after the field's own type
+	for _, name := range typeNames {
+		g.generated[name] = true
+	}
+	for _, name := range typeNames {
+
+		if err := g.generateType(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by marshalddb-gen -type %s; DO NOT EDIT.\n\n", strings.Join(typeNames, ","))
+	fmt.Fprintf(&src, "package %s\n\n", pkgName)
+	src.WriteString("import (\n")
+	var std, paths []string
+	for path := range g.imports {
+		if strings.Contains(path, ".") {
+			paths = append(paths, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(paths)
+	for _, path := range std {
+		fmt.Fprintf(&src, "%q\n", path)
+	}
+	if len(std) != 0 {
+		src.WriteString("\n")
+	}
+	for _, path := range paths {
+		fmt.Fprintf(&src, "%q\n", path)
+	}
+	src.WriteString(")\n")
+	src.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return formatted, nil
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]bool
+	// generated are the names of the types whose methods are generated
+	generated map[string]bool
+	buf       bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generateType writes the methods of the struct type named name
+func (g *generator) generateType(name string) error {
+
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("type %s not found", name)
+	}
+	if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+		return fmt.Errorf("type %s is not a struct", name)
+	}
+
+	fields, err := g.structFields(obj.Type())
+	if err != nil {
+		return fmt.Errorf("type %s: %v", name, err)
+	}
+
+	plain := "marshalddb" + name
+	g.printf("\n// %s has the fields of %s but none of its methods, so that\n", plain, name)
+	g.printf("// marshalddb converts it by reflection\n")
+	g.printf("type %s %s\n", plain, name)
+
+	g.printf("\n// MarshalDDBGenerated implements marshalddb.Generated, so that an\n")
+	g.printf("// Encoder or Decoder with options of its own converts %s by reflection\n", name)
+	g.printf("func (%s) MarshalDDBGenerated() {}\n", name)
+
+	g.printf("\n// marshalddb%sFields convert the fields of %s that the generated\n", name, name)
+	g.printf("// code leaves to marshalddb, with their tags parsed once\n")
+	g.printf("var marshalddb%sFields = struct {\n", name)
+	for _, f := range fields {
+		g.printf("%s *marshalddb.Field\n", f.id)
+	}
+	g.printf("}{\n")
+	for _, f := range fields {
+		g.printf("%s: marshalddb.NewField(%q, %q),\n", f.id, f.name, f.opts)
+	}
+	g.printf("}\n")
+
+	g.generateMarshal(name, fields)
+	g.generateUnmarshal(name, plain, fields)
+	return nil
+}
+
+// structFields returns the fields of the struct type t that are converted,
+// promoting the fields of embedded structs by the same rules as
+// marshalddb: a field at a shallower depth hides deeper fields of the same
+// name, a tagged field wins over untagged fields at the same depth, and
+// any other conflict leaves no field by that name
+func (g *generator) structFields(t types.Type) ([]field, error) {
+
+	// embeddedStruct is a struct whose fields are promoted
+	type embeddedStruct struct {
+		typ   types.Type
+		index []int
+		sel   string
+		ptrs  []embedded
+	}
+
+	var (
+		current []embeddedStruct
+		next    = []embeddedStruct{{typ: t}}
+
+		// types of the embedded structs at the current and next depth
+		count     = map[types.Type]int{}
+		nextCount = map[types.Type]int{}
+
+		visited = map[types.Type]bool{}
+		fields  []field
+	)
+
+	for len(next) > 0 {
+
+		current, next = next, nil
+		count, nextCount = nextCount, map[types.Type]int{}
+
+		for _, e := range current {
+
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			est := e.typ.Underlying().(*types.Struct)
+			for i := 0; i < est.NumFields(); i++ {
+
+				v := est.Field(i)
+				ft := v.Type()
+				ptr, isPtr := ft.(*types.Pointer)
+				if isPtr {
+					ft = ptr.Elem()
+				}
+				_, isStruct := ft.Underlying().(*types.Struct)
+
+				// the exported fields of an unexported embedded struct
+				// are still promoted
+				if !v.Exported() && !(v.Anonymous() && isStruct) {
+					continue
+				}
+
+				f, skip := parseTag(v.Name(), reflect.StructTag(est.Tag(i)))
+				if skip {
+					continue
+				}
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				// an untagged embedded struct has its fields promoted,
+				// anything else is a field of its own
+				if f.tagged || !v.Anonymous() || !isStruct {
+
+					if !v.Exported() {
+						continue
+					}
+
+					f.sel = e.sel + v.Name()
+					f.id = strings.Replace(f.sel, ".", "_", -1)
+					f.index = index
+					f.ptrs = e.ptrs
+					g.setKind(&f, v.Type())
+					fields = append(fields, f)
+					if count[e.typ] > 1 {
+						// the same struct was embedded more than once at
+						// this depth, so its fields annihilate one another
+						fields = append(fields, f)
+					}
+					continue
+				}
+
+				if !v.Exported() && v.Pkg() != g.pkg {
+					return nil, fmt.Errorf("embedded struct %s from another package is unexported", v.Name())
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+
+					es := embeddedStruct{
+						typ:   ft,
+						index: index,
+						sel:   e.sel + v.Name() + ".",
+						ptrs:  e.ptrs,
+					}
+					if isPtr {
+						es.ptrs = append(es.ptrs[:len(es.ptrs):len(es.ptrs)], embedded{
+							sel:      e.sel + v.Name(),
+							typ:      g.typeString(ft),
+							exported: v.Exported(),
+						})
+					}
+					next = append(next, es)
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+
+		switch {
+
+		case fields[i].name != fields[j].name:
+			return fields[i].name < fields[j].name
+
+		case len(fields[i].index) != len(fields[j].index):
+			return len(fields[i].index) < len(fields[j].index)
+
+		case fields[i].tagged != fields[j].tagged:
+			return fields[i].tagged
+		}
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	// drop any fields hidden by the shadowing rules
+	var out []field
+	for advance, i := 0, 0; i < len(fields); i += advance {
+
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fields[i].name {
+				break
+			}
+		}
+
+		same := fields[i : i+advance]
+		if len(same) > 1 &&
+			len(same[0].index) == len(same[1].index) &&
+			same[0].tagged == same[1].tagged {
+			continue
+		}
+		out = append(out, same[0])
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return lessIndex(out[i].index, out[j].index)
+	})
+
+	return out, nil
+}
+
+// lessIndex reports whether the field at index a comes before the field
+// at index b
+func lessIndex(a, b []int) bool {
+
+	for k, ak := range a {
+		if k >= len(b) {
+			return false
+		}
+		if ak != b[k] {
+			return ak < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// typeString returns t as it is written in the generated code, importing
+// the packages it refers to
+func (g *generator) typeString(t types.Type) string {
+
+	return types.TypeString(t, func(pkg *types.Package) string {
+
+		if pkg == g.pkg {
+			return ""
+		}
+		g.imports[pkg.Path()] = true
+		return pkg.Name()
+	})
+}
+
+// parseTag reads the name and options of a field from its `dynamodb` tag,
+// falling back to its `json` tag
+func parseTag(goName string, tag reflect.StructTag) (field, bool) {
+
+	f := field{
+		goName: goName,
+		name:   goName,
+	}
+
+	ddb, hasDDB := tag.Lookup("dynamodb")
+	js := tag.Get("json")
+
+	var name string
+	switch {
+
+	case ddb == "-" || (!hasDDB && js == "-"):
+		return f, true
+
+	case hasDDB:
+		name, f.opts = splitTag(ddb)
+		if name == "" && js != "-" {
+			name, _ = splitTag(js)
+		}
+
+	default:
+		name, f.opts = splitTag(js)
+	}
+
+	if name != "" {
+		f.name = name
+		f.tagged = true
+	}
+
+	return f, false
+}
+
+func splitTag(tag string) (string, string) {
+
+	if i := strings.Index(tag, ","); i != -1 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// conversionMethods are the methods marshalddb prefers over a type's kind
+var conversionMethods = []string{
+	"MarshalDynamoDBAttributeValue",
+	"UnmarshalDynamoDBAttributeValue",
+	"MarshalText",
+	"UnmarshalText",
+	"MarshalBinary",
+	"UnmarshalBinary",
+}
+
+// setKind sets how a field of type t is converted
+func (g *generator) setKind(f *field, t types.Type) {
+
+	f.kind = delegated
+	switch u := t.(type) {
+
+	case *types.Pointer:
+		g.setScalarKind(f, u.Elem())
+		if f.kind == delegated {
+			return
+		}
+		f.ptr = true
+		f.typ = g.typeString(u.Elem())
+
+	case *types.Slice:
+		// byte slices are B attributes, and the set and binary options
+		// are left to marshalddb
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Uint8 {
+			return
+		}
+
+		var elem field
+		g.setScalarKind(&elem, u.Elem())
+		switch elem.kind {
+
+		case stringKind, intKind, uintKind, floatKind:
+			if f.has("set") || f.has("binary") {
+				return
+			}
+
+		case generatedKind:
+			// whatever the tag, these are stored as an L
+
+		default:
+			return
+		}
+		f.kind = sliceKind
+		f.elem = &elem
+		f.typ = g.typeString(t)
+
+	case *types.Map:
+		// maps stored as JSON, or with binary elements, are left to
+		// marshalddb
+		if f.has("json") || f.has("binary") {
+			return
+		}
+
+		var key, elem field
+		g.setScalarKind(&key, u.Key())
+		g.setScalarKind(&elem, u.Elem())
+		if key.kind != stringKind {
+			return
+		}
+		switch elem.kind {
+
+		case stringKind, intKind, uintKind, floatKind:
+
+		case boolKind:
+			// a map[string]bool tagged as a set is stored as one
+			if f.has("set") {
+				return
+			}
+
+		default:
+			return
+		}
+		f.kind = mapKind
+		f.key = key.conv
+		f.elem = &elem
+		f.typ = g.typeString(t)
+
+	default:
+		g.setScalarKind(f, t)
+	}
+}
+
+// setScalarKind sets how a field holding a single value of type t is
+// converted
+func (g *generator) setScalarKind(f *field, t types.Type) {
+
+	f.kind = delegated
+	if named, ok := t.(*types.Named); ok {
+
+		// types from other packages may be treated specially by
+		// marshalddb, of which only time.Time, time.Duration and
+		// marshalddb.Number are converted by the generated code
+		if pkg := named.Obj().Pkg(); pkg != g.pkg {
+
+			switch {
+
+			case pkg == nil:
+
+			case pkg.Path() == marshalddbPath && named.Obj().Name() == "Number":
+				f.kind = numberKind
+				f.conv = "marshalddb.Number"
+				f.typ = f.conv
+
+			case pkg.Path() != "time":
+
+			case named.Obj().Name() == "Time" && !f.has("json"):
+				f.kind = timeKind
+				f.typ = "time.Time"
+
+			case named.Obj().Name() == "Duration":
+				f.kind = durationKind
+				f.typ = "time.Duration"
+			}
+			return
+		}
+
+		ms := types.NewMethodSet(types.NewPointer(t))
+
+		// structs with generated methods, whether from this run or an
+		// earlier one, are converted by calling them
+		if _, ok := t.Underlying().(*types.Struct); ok && !f.has("json") &&
+			(g.generated[named.Obj().Name()] || ms.Lookup(nil, "MarshalDDBGenerated") != nil) {
+			f.kind = generatedKind
+			f.typ = named.Obj().Name()
+			return
+		}
+
+		for _, m := range conversionMethods {
+			if ms.Lookup(nil, m) != nil {
+				return
+			}
+		}
+		f.conv = named.Obj().Name()
+	}
+
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return
+	}
+
+	f.typ = f.conv
+	if f.typ == "" {
+		f.typ = b.Name()
+	}
+
+	switch b.Kind() {
+
+	case types.String:
+		f.kind = stringKind
+
+	case types.Bool:
+		f.kind = boolKind
+
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+		f.kind = intKind
+
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+		f.kind = uintKind
+
+	case types.Float32, types.Float64:
+		f.kind = floatKind
+	}
+
+	switch b.Kind() {
+
+	case types.Int8, types.Uint8:
+		f.bits = 8
+
+	case types.Int16, types.Uint16:
+		f.bits = 16
+
+	case types.Int32, types.Uint32, types.Float32:
+		f.bits = 32
+
+	case types.Int64, types.Uint64, types.Float64:
+		f.bits = 64
+	}
+}
+
+func (g *generator) generateMarshal(name string, fields []field) {
+
+	g.printf("\n// MarshalDynamoDBAttributeValue converts v into an M attribute\n")
+	g.printf("func (v %s) MarshalDynamoDBAttributeValue() (*dynamodb.AttributeValue, error) {\n\n", name)
+	g.printf("m := make(map[string]*dynamodb.AttributeValue, %d)\n", len(fields))
+
+	for _, f := range fields {
+
+		key := strconv.Quote(f.name)
+		value := "v." + f.sel
+		conv := "marshalddb" + name + "Fields." + f.id
+		g.printf("\n")
+
+		// fields promoted through a nil embedded pointer are not written
+		if len(f.ptrs) != 0 {
+			var notNil []string
+			for _, p := range f.ptrs {
+				notNil = append(notNil, "v."+p.sel+" != nil")
+			}
+			g.printf("if %s {\n", strings.Join(notNil, " && "))
+		}
+
+		// the errors of values DynamoDB cannot store are returned by
+		// marshalddb, with the field's path
+		fail := fmt.Sprintf("_, err = %s.Marshal(&%s)\nreturn nil, err\n", conv, value)
+
+		switch {
+
+		case f.ptr:
+			// a nil pointer is omitted, but the value it points to is
+			// written even if it is empty
+			g.printf("if %s != nil {\n", value)
+			if f.kind == generatedKind {
+				g.marshalGenerated(key, value, fail)
+			} else {
+				g.printf("p := *%s\n", value)
+				g.marshalScalar(f, key, "p", conv, value, true)
+			}
+			g.printf("}")
+			g.marshalNull(f, key)
+			g.printf("\n")
+
+		case f.kind == generatedKind:
+			g.marshalGenerated(key, value, fail)
+
+		case f.kind == sliceKind:
+			g.marshalSlice(f, key, value, fail)
+
+		case f.kind == mapKind:
+			g.marshalMap(f, key, value, fail)
+
+		case f.kind == delegated:
+			g.printf("if a, err := %s.Marshal(&%s); err != nil {\n", conv, value)
+			g.printf("return nil, err\n")
+			g.printf("} else if a != nil {\n")
+			g.printf("m[%s] = a\n", key)
+			g.printf("}\n")
+
+		default:
+			g.marshalScalar(f, key, value, conv, value, false)
+		}
+
+		if len(f.ptrs) != 0 {
+			g.printf("}\n")
+		}
+	}
+
+	g.printf("\nreturn &dynamodb.AttributeValue{\nM: m,\n}, nil\n")
+	g.printf("}\n")
+}
+
+// marshalNull continues an if statement omitting a field with an else
+// branch writing NULL, if the field is tagged nullable but not omitempty
+func (g *generator) marshalNull(f field, key string) {
+
+	if f.has("nullable") && !f.has("omitempty") {
+		g.imports["github.com/aws/aws-sdk-go/aws"] = true
+		g.printf(" else {\n")
+		g.printf("m[%s] = &dynamodb.AttributeValue{\nNULL: aws.Bool(true),\n}\n", key)
+		g.printf("}")
+	}
+}
+
+// marshalScalar writes the field f holding value, which is read from the
+// struct field field. A pointee is the value a non-nil pointer points to,
+// which is written even if it is empty.
+func (g *generator) marshalScalar(f field, key, value, conv, field string, pointee bool) {
+
+	g.imports["github.com/aws/aws-sdk-go/aws"] = true
+	omitEmpty := f.has("omitempty") && !pointee
+
+	switch f.kind {
+
+	case stringKind, numberKind:
+		// empty strings are never written
+		g.printf("if %s != \"\" {\n", value)
+
+		member := "S"
+		if f.kind == numberKind {
+			if !f.has("string") {
+				member = "N"
+			}
+			g.printf("if err := marshalddb.CheckNumber(string(%s)); err == nil {\n", value)
+		}
+		if f.has("binary") && f.kind == stringKind {
+			g.printf("m[%s] = &dynamodb.AttributeValue{\nB: []byte(%s),\n}\n", key, value)
+		} else {
+			g.printf("m[%s] = &dynamodb.AttributeValue{\n%s: aws.String(%s),\n}\n", key, member, toBasic(f, "string", value))
+		}
+		if f.kind == numberKind {
+			g.printf("} else if _, err := %s.Marshal(&%s); err != nil {\n", conv, field)
+			g.printf("// marshalddb returns the error with the field's path\n")
+			g.printf("return nil, err\n")
+			g.printf("}\n")
+		}
+
+		if f.has("nullable") && (pointee || !f.has("omitempty")) {
+			g.printf("} else {\n")
+			g.printf("m[%s] = &dynamodb.AttributeValue{\nNULL: aws.Bool(true),\n}\n", key)
+		}
+		g.printf("}\n")
+
+	case boolKind, intKind, uintKind:
+		var nonZero, member, text string
+		switch f.kind {
+
+		case boolKind:
+			nonZero = toBasic(f, "bool", value)
+			member = "BOOL: aws.Bool(" + toBasic(f, "bool", value) + ")"
+			text = "strconv.FormatBool(" + toBasic(f, "bool", value) + ")"
+
+		case intKind:
+			nonZero = value + " != 0"
+			text = "strconv.FormatInt(int64(" + value + "), 10)"
+			member = "N: aws.String(" + text + ")"
+
+		case uintKind:
+			nonZero = value + " != 0"
+			text = "strconv.FormatUint(uint64(" + value + "), 10)"
+			member = "N: aws.String(" + text + ")"
+		}
+		if f.kind != boolKind || f.has("string") {
+			g.imports["strconv"] = true
+		}
+		if f.has("string") {
+			member = "S: aws.String(" + text + ")"
+		}
+
+		if omitEmpty {
+			g.printf("if %s {\n", nonZero)
+		}
+		g.printf("m[%s] = &dynamodb.AttributeValue{\n%s,\n}\n", key, member)
+		if omitEmpty {
+			g.printf("}\n")
+		}
+
+	case floatKind, timeKind, durationKind:
+		member := "N"
+		if f.has("string") {
+			member = "S"
+		}
+
+		// values that DynamoDB may not be able to store are checked
+		// by the statement check and the condition ok
+		var nonZero, check, ok, text string
+		switch {
+
+		case f.kind == floatKind:
+			f64 := value
+			if f.conv != "" || f.bits != 64 {
+				f64 = "float64(" + value + ")"
+			}
+			nonZero = value + " != 0"
+			check = fmt.Sprintf("n, err := marshalddb.FormatFloat(%s, %d)", f64, f.bits)
+			ok = "err == nil"
+			text = "n"
+
+		case f.kind == durationKind:
+			nonZero = value + " != 0"
+			text = value + ".String()"
+			if member == "N" {
+				g.imports["strconv"] = true
+				text = "strconv.FormatInt(int64(" + value + "), 10)"
+			}
+
+		case f.unixTime() == "":
+			g.imports["time"] = true
+			nonZero = "!" + value + ".IsZero()"
+			member = "S"
+			text = value + ".Format(time.RFC3339Nano)"
+
+		case f.unixTime() == "unix":
+			g.imports["strconv"] = true
+			nonZero = "!" + value + ".IsZero()"
+			member = "N"
+			text = "strconv.FormatInt(" + value + ".Unix(), 10)"
+
+		case f.unixTime() == "unixmilli":
+			g.imports["strconv"] = true
+			nonZero = "!" + value + ".IsZero()"
+			member = "N"
+			text = "strconv.FormatInt(" + value + ".Unix()*1e3+int64(" + value + ".Nanosecond())/1e6, 10)"
+
+		default:
+			g.imports["strconv"] = true
+			g.imports["time"] = true
+			nonZero = "!" + value + ".IsZero()"
+			member = "N"
+			check = "n := " + value + ".UnixNano()"
+			ok = "time.Unix(0, n).Equal(" + value + ")"
+			text = "strconv.FormatInt(n, 10)"
+		}
+
+		if omitEmpty {
+			g.printf("if %s {\n", nonZero)
+		}
+		if check != "" {
+			g.printf("if %s; %s {\n", check, ok)
+		}
+		g.printf("m[%s] = &dynamodb.AttributeValue{\n%s: aws.String(%s),\n}\n", key, member, text)
+		if check != "" {
+			g.printf("} else if _, err := %s.Marshal(&%s); err != nil {\n", conv, field)
+			g.printf("// marshalddb returns the error with the field's path\n")
+			g.printf("return nil, err\n")
+			g.printf("}\n")
+		}
+		if omitEmpty {
+			g.printf("}\n")
+		}
+	}
+}
+
+// marshalGenerated writes a value with generated methods by calling them
+func (g *generator) marshalGenerated(key, value, fail string) {
+
+	g.printf("if a, err := %s.MarshalDynamoDBAttributeValue(); err != nil {\n", value)
+	g.printf("%s", fail)
+	g.printf("} else if a != nil {\n")
+	g.printf("m[%s] = a\n", key)
+	g.printf("}\n")
+}
+
+// marshalSlice writes a slice as an L attribute if it is tagged as a list
+// or holds values with generated methods, and otherwise as an SS or NS
+// attribute
+func (g *generator) marshalSlice(f field, key, value, fail string) {
+
+	g.imports["github.com/aws/aws-sdk-go/aws"] = true
+	elem := *f.elem
+	list := f.has("list") || elem.kind == generatedKind
+
+	// a nil slice is omitted, as is an empty one unless it is a list,
+	// since DynamoDB does not allow empty sets
+	if list && !f.has("omitempty") {
+		g.printf("if %s != nil {\n", value)
+	} else {
+		g.printf("if len(%s) != 0 {\n", value)
+	}
+
+	switch {
+
+	case list:
+		g.printf("l := make([]*dynamodb.AttributeValue, len(%s))\n", value)
+		g.printf("for i := range %s {\n", value)
+		e := value + "[i]"
+		switch elem.kind {
+
+		case generatedKind:
+			g.printf("a, err := %s.MarshalDynamoDBAttributeValue()\n", e)
+			g.printf("if err != nil {\n%s}\n", fail)
+			g.printf("l[i] = a\n")
+
+		case stringKind:
+			// an empty string is a NULL element
+			g.printf("if %s != \"\" {\n", e)
+			g.printf("l[i] = &dynamodb.AttributeValue{\nS: aws.String(%s),\n}\n", toBasic(elem, "string", e))
+			g.printf("} else {\n")
+			g.printf("l[i] = &dynamodb.AttributeValue{\nNULL: aws.Bool(true),\n}\n")
+			g.printf("}\n")
+
+		default:
+			n := g.formatNumber(elem, e, fail)
+			g.printf("l[i] = &dynamodb.AttributeValue{\nN: aws.String(%s),\n}\n", n)
+		}
+		g.printf("}\n")
+		g.printf("m[%s] = &dynamodb.AttributeValue{\nL: l,\n}\n", key)
+
+	case elem.kind == stringKind:
+		g.printf("ss := make([]*string, len(%s))\n", value)
+		g.printf("for i := range %s {\n", value)
+		g.printf("ss[i] = aws.String(%s)\n", toBasic(elem, "string", value+"[i]"))
+		g.printf("}\n")
+		g.printf("m[%s] = &dynamodb.AttributeValue{\nSS: ss,\n}\n", key)
+
+	default:
+		g.printf("ns := make([]*string, len(%s))\n", value)
+		g.printf("for i := range %s {\n", value)
+		n := g.formatNumber(elem, value+"[i]", fail)
+		g.printf("ns[i] = aws.String(%s)\n", n)
+		g.printf("}\n")
+		g.printf("m[%s] = &dynamodb.AttributeValue{\nNS: ns,\n}\n", key)
+	}
+
+	g.printf("}")
+	g.marshalNull(f, key)
+	g.printf("\n")
+}
+
+// marshalMap writes a map as an M attribute, omitting empty strings
+func (g *generator) marshalMap(f field, key, value, fail string) {
+
+	g.imports["github.com/aws/aws-sdk-go/aws"] = true
+	elem := *f.elem
+
+	// a nil map is omitted, but an empty one is an empty M
+	if f.has("omitempty") {
+		g.printf("if len(%s) != 0 {\n", value)
+	} else {
+		g.printf("if %s != nil {\n", value)
+	}
+
+	k := "k"
+	if f.key != "" {
+		k = "string(k)"
+	}
+
+	g.printf("mm := make(map[string]*dynamodb.AttributeValue, len(%s))\n", value)
+	g.printf("for k, e := range %s {\n", value)
+	switch elem.kind {
+
+	case stringKind:
+		g.printf("if e != \"\" {\n")
+		g.printf("mm[%s] = &dynamodb.AttributeValue{\nS: aws.String(%s),\n}\n", k, toBasic(elem, "string", "e"))
+		g.printf("}\n")
+
+	case boolKind:
+		g.printf("mm[%s] = &dynamodb.AttributeValue{\nBOOL: aws.Bool(%s),\n}\n", k, toBasic(elem, "bool", "e"))
+
+	default:
+		n := g.formatNumber(elem, "e", fail)
+		g.printf("mm[%s] = &dynamodb.AttributeValue{\nN: aws.String(%s),\n}\n", k, n)
+	}
+	g.printf("}\n")
+	g.printf("m[%s] = &dynamodb.AttributeValue{\nM: mm,\n}\n", key)
+
+	g.printf("}")
+	g.marshalNull(f, key)
+	g.printf("\n")
+}
+
+// formatNumber returns an expression formatting the number e of the
+// element elem. A float is first formatted into n by statements written
+// before the expression, which fail if DynamoDB cannot store it.
+func (g *generator) formatNumber(elem field, e, fail string) string {
+
+	switch elem.kind {
+
+	case intKind:
+		g.imports["strconv"] = true
+		return "strconv.FormatInt(int64(" + e + "), 10)"
+
+	case uintKind:
+		g.imports["strconv"] = true
+		return "strconv.FormatUint(uint64(" + e + "), 10)"
+	}
+
+	g.printf("n, err := marshalddb.FormatFloat(float64(%s), %d)\n", e, elem.bits)
+	g.printf("if err != nil {\n%s}\n", fail)
+	return "n"
+}
+
+func (g *generator) generateUnmarshal(name, plain string, fields []field) {
+
+	g.printf("\n// UnmarshalDynamoDBAttributeValue sets v from an M attribute\n")
+	g.printf("func (v *%s) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {\n\n", name)
+	if len(fields) == 0 {
+		// with no field to match, the loop below would be unreachable
+		g.printf("// no field is converted, so everything is left to reflection\n")
+		g.printf("return marshalddb.Unmarshal(av, (*%s)(v))\n", plain)
+		g.printf("}\n")
+		return
+	}
+	g.printf("if av == nil || av.M == nil {\n")
+	g.printf("// anything but an M is left to reflection\n")
+	g.printf("return marshalddb.Unmarshal(av, (*%s)(v))\n", plain)
+	g.printf("}\n\n")
+
+	g.printf("for attr, a := range av.M {\n\n")
+	g.printf("if a == nil {\ncontinue\n}\n\n")
+	g.generateFieldName(fields)
+	g.printf("\nswitch name {\n")
+
+	for _, f := range fields {
+
+		key := strconv.Quote(f.name)
+		value := "v." + f.sel
+		conv := "marshalddb" + name + "Fields." + f.id
+		g.printf("\ncase %s:\n", key)
+
+		// embedded pointers are allocated however the attribute is read,
+		// except that marshalddb reports a nil pointer to an unexported
+		// struct, which it cannot allocate
+		for _, p := range f.ptrs {
+
+			g.printf("if v.%s == nil {\n", p.sel)
+			if p.exported {
+				g.printf("v.%s = new(%s)\n", p.sel, p.typ)
+			} else {
+				g.printf("// marshalddb reports the attribute it cannot read\n")
+				g.printf("return marshalddb.Unmarshal(av, (*%s)(v))\n", plain)
+			}
+			g.printf("}\n")
+		}
+
+		// the common attribute for each kind is set directly, anything
+		// else is left to marshalddb
+		assign := value + " = %s"
+		if f.ptr {
+			// NULL leaves a pointer nil, anything else is set on the
+			// value it points to
+			g.printf("if a.NULL != nil && *a.NULL {\n%s = nil\ncontinue\n}\n", value)
+			assign = fmt.Sprintf("if %s == nil {\n%s = new(%s)\n}\n*%s = %%s", value, value, f.typ, value)
+		}
+
+		switch f.kind {
+
+		case generatedKind:
+			if f.ptr {
+				g.printf("if %s == nil {\n%s = new(%s)\n}\n", value, value, f.typ)
+			}
+			g.printf("if err := %s.UnmarshalDynamoDBAttributeValue(a); err == nil {\ncontinue\n}\n", value)
+
+		case sliceKind:
+			g.unmarshalSlice(f, value)
+
+		case mapKind:
+			g.unmarshalMap(f, value)
+
+		default:
+			g.unmarshalScalar(f, assign)
+		}
+
+		g.printf("if err := %s.Unmarshal(attr, a, &%s); err != nil {\n", conv, value)
+		g.printf("return err\n")
+		g.printf("}\n")
+	}
+
+	g.printf("}\n")
+	g.printf("}\n\n")
+	g.printf("return nil\n")
+	g.printf("}\n")
+}
+
+// unmarshalScalar sets a field from the common attribute of its kind,
+// with assign formatting the statement that sets it to an expression
+func (g *generator) unmarshalScalar(f field, assign string) {
+
+	set := func(expr string) {
+		g.printf(assign+"\ncontinue\n", expr)
+	}
+
+	switch f.kind {
+
+	case stringKind:
+		if f.has("binary") {
+			bytes := "string(a.B)"
+			if f.conv != "" {
+				bytes = f.conv + "(a.B)"
+			}
+			g.printf("if a.B != nil {\n")
+			set(bytes)
+			g.printf("}\n")
+		} else {
+			g.printf("if a.S != nil {\n")
+			set(fromBasic(f, "*a.S"))
+			g.printf("}\n")
+		}
+
+	case numberKind:
+		member := "N"
+		if f.has("string") {
+			member = "S"
+		}
+		g.printf("if a.%s != nil {\n", member)
+		set(fromBasic(f, "*a."+member))
+		g.printf("}\n")
+
+	case boolKind:
+		if f.has("string") {
+			break
+		}
+		g.printf("if a.BOOL != nil {\n")
+		set(fromBasic(f, "*a.BOOL"))
+		g.printf("}\n")
+
+	case intKind, uintKind, floatKind:
+		member := "N"
+		if f.has("string") {
+			member = "S"
+		}
+		g.printf("if a.%s != nil {\n", member)
+		g.printf("if n, err := %s; err == nil {\n", g.parseNumber(f, "*a."+member))
+		set(f.typ + "(n)")
+		g.printf("}\n")
+		g.printf("}\n")
+
+	case timeKind:
+		g.imports["time"] = true
+		var t string
+		switch f.unixTime() {
+
+		case "":
+			g.printf("if a.S != nil {\n")
+			g.printf("if t, err := time.Parse(time.RFC3339Nano, *a.S); err == nil {\n")
+			set("t")
+			g.printf("}\n")
+			g.printf("}\n")
+
+		case "unix":
+			t = "time.Unix(n, 0)"
+
+		case "unixmilli":
+			t = "time.Unix(n/1e3, n%1e3*1e6)"
+
+		case "unixnano":
+			t = "time.Unix(0, n)"
+		}
+		if t != "" {
+			g.imports["strconv"] = true
+			g.printf("if a.N != nil {\n")
+			g.printf("if n, err := strconv.ParseInt(*a.N, 10, 64); err == nil {\n")
+			set(t + ".UTC()")
+			g.printf("}\n")
+			g.printf("}\n")
+		}
+
+	case durationKind:
+		g.imports["time"] = true
+		if f.has("string") {
+			g.printf("if a.S != nil {\n")
+			g.printf("if d, err := time.ParseDuration(*a.S); err == nil {\n")
+			set("d")
+			g.printf("}\n")
+			g.printf("}\n")
+			break
+		}
+		g.imports["strconv"] = true
+		g.printf("if a.N != nil {\n")
+		g.printf("if n, err := strconv.ParseInt(*a.N, 10, 64); err == nil {\n")
+		set("time.Duration(n)")
+		g.printf("}\n")
+		g.printf("}\n")
+	}
+}
+
+// parseNumber returns an expression parsing the text of a number into
+// the integer or float n of the kind of f, and err
+func (g *generator) parseNumber(f field, text string) string {
+
+	g.imports["strconv"] = true
+	switch f.kind {
+
+	case uintKind:
+		return fmt.Sprintf("strconv.ParseUint(%s, 10, %d)", text, f.bits)
+
+	case floatKind:
+		return fmt.Sprintf("strconv.ParseFloat(%s, %d)", text, f.bits)
+	}
+	return fmt.Sprintf("strconv.ParseInt(%s, 10, %d)", text, f.bits)
+}
+
+// unmarshalSlice sets a slice from an L attribute or, for strings and
+// numbers, an SS or NS attribute. Each is decoded into a new slice that
+// only replaces the field once every element is set.
+func (g *generator) unmarshalSlice(f field, value string) {
+
+	elem := *f.elem
+
+	// sets hold the text of each member
+	var set string
+	switch elem.kind {
+
+	case stringKind:
+		set = "SS"
+
+	case intKind, uintKind, floatKind:
+		set = "NS"
+	}
+	if set != "" {
+		g.printf("if len(a.%s) != 0 {\n", set)
+		g.printf("s := make(%s, len(a.%s))\n", f.typ, set)
+		g.printf("ok := true\n")
+		g.printf("for i, e := range a.%s {\n", set)
+		g.printf("if e == nil {\nok = false\nbreak\n}\n")
+		g.decodeElem(elem, "*e", "s[i]")
+		g.printf("}\n")
+		g.printf("if ok {\n%s = s\ncontinue\n}\n", value)
+		g.printf("}\n")
+	}
+
+	// a nil element of an L is left as the zero value
+	g.printf("if a.L != nil {\n")
+	g.printf("s := make(%s, len(a.L))\n", f.typ)
+	g.printf("ok := true\n")
+	g.printf("for i, e := range a.L {\n")
+	g.printf("if e == nil {\ncontinue\n}\n")
+	switch elem.kind {
+
+	case generatedKind:
+		g.printf("if err := s[i].UnmarshalDynamoDBAttributeValue(e); err != nil {\nok = false\nbreak\n}\n")
+
+	case stringKind:
+		g.printf("if e.S == nil {\nok = false\nbreak\n}\n")
+		g.decodeElem(elem, "*e.S", "s[i]")
+
+	default:
+		g.printf("if e.N == nil {\nok = false\nbreak\n}\n")
+		g.decodeElem(elem, "*e.N", "s[i]")
+	}
+	g.printf("}\n")
+	g.printf("if ok {\n%s = s\ncontinue\n}\n", value)
+	g.printf("}\n")
+}
+
+// unmarshalMap sets the elements of an M attribute onto a map, which is
+// allocated if it is nil and otherwise added to. The elements are decoded
+// into a new map and only added once every element is set.
+func (g *generator) unmarshalMap(f field, value string) {
+
+	elem := *f.elem
+	k := "k"
+	if f.key != "" {
+		k = f.key + "(k)"
+	}
+
+	member := "N"
+	switch elem.kind {
+
+	case stringKind:
+		member = "S"
+
+	case boolKind:
+		member = "BOOL"
+	}
+
+	g.printf("if a.M != nil {\n")
+	g.printf("mm := make(%s, len(a.M))\n", f.typ)
+	g.printf("ok := true\n")
+	g.printf("for k, e := range a.M {\n")
+	g.printf("if e == nil {\ncontinue\n}\n")
+	g.printf("if e.%s == nil {\nok = false\nbreak\n}\n", member)
+	g.decodeElem(elem, "*e."+member, "mm["+k+"]")
+	g.printf("}\n")
+	g.printf("if ok {\n")
+	g.printf("if %s == nil {\n%s = make(%s, len(mm))\n}\n", value, value, f.typ)
+	g.printf("for k, e := range mm {\n%s[k] = e\n}\n", value)
+	g.printf("continue\n")
+	g.printf("}\n")
+	g.printf("}\n")
+}
+
+// decodeElem sets target from text, the value of an element of kind
+// elem, or sets ok to false and breaks out of the loop if it can't
+func (g *generator) decodeElem(elem field, text, target string) {
+
+	switch elem.kind {
+
+	case stringKind, boolKind:
+		g.printf("%s = %s\n", target, fromBasic(elem, text))
+
+	default:
+		g.printf("n, err := %s\n", g.parseNumber(elem, text))
+		g.printf("if err != nil {\nok = false\nbreak\n}\n")
+		g.printf("%s = %s(n)\n", target, elem.typ)
+	}
+}
+
+// generateFieldName writes a switch declaring name as the attribute name
+// of the field that attr is decoded into. As in marshalddb, attr is
+// matched to a field by its attribute name, then by its Go name, then by
+// one of its aliases and finally by its attribute name without regard to
+// case. Attributes matching no field are skipped.
+func (g *generator) generateFieldName(fields []field) {
+
+	g.imports["strings"] = true
+
+	// the first field in order wins any name it shares with others
+	seen := make(map[string]bool)
+	var exact []string
+	other := make(map[string][]string)
+	for _, f := range fields {
+
+		if !seen[f.name] {
+			seen[f.name] = true
+			exact = append(exact, strconv.Quote(f.name))
+		}
+	}
+	for _, f := range fields {
+
+		if !seen[f.goName] {
+			seen[f.goName] = true
+			other[f.name] = append(other[f.name], strconv.Quote(f.goName))
+		}
+	}
+	for _, f := range fields {
+
+		for _, a := range f.aliases() {
+			if !seen[a] {
+				seen[a] = true
+				other[f.name] = append(other[f.name], strconv.Quote(a))
+			}
+		}
+	}
+
+	g.printf("// match attr to a field as marshalddb would\n")
+	g.printf("name := attr\n")
+	g.printf("switch attr {\n")
+	if len(exact) != 0 {
+		g.printf("\n// the attribute names of the fields\n")
+		g.printf("case %s:\n", strings.Join(exact, ",\n"))
+	}
+	for _, f := range fields {
+
+		if names := other[f.name]; len(names) != 0 {
+			g.printf("\ncase %s:\nname = %q\n", strings.Join(names, ", "), f.name)
+		}
+	}
+
+	g.printf("\ndefault:\n")
+	g.printf("switch strings.ToLower(attr) {\n")
+	folded := make(map[string]bool)
+	for _, f := range fields {
+
+		lower := strings.ToLower(f.name)
+		if !folded[lower] {
+			folded[lower] = true
+			g.printf("\ncase %q:\nname = %q\n", lower, f.name)
+		}
+	}
+	g.printf("\ndefault:\n")
+	g.printf("// no field has the attribute's name\n")
+	g.printf("continue\n")
+	g.printf("}\n")
+	g.printf("}\n")
+}
+
+// toBasic converts the value of a field of a named type to the
+// predeclared type typ
+func toBasic(f field, typ, value string) string {
+
+	if f.conv == "" {
+		return value
+	}
+	return typ + "(" + value + ")"
+}
+
+// fromBasic converts expr to the named type of a field
+func fromBasic(f field, expr string) string {
+
+	if f.conv == "" {
+		return expr
+	}
+	return f.conv + "(" + expr + ")"
+}
@@ -0,0 +1,111 @@
+// Package example holds types converted by methods generated by
+// marshalddb-gen, which are tested against marshalddb's reflective
+// conversion of the same types.
+package example
+
+import (
+	"time"
+
+	"github.com/jessejlt/marshalddb"
+)
+
+//go:generate marshalddb-gen -type Order,Item,Receipt,User,Cart
+
+// Status is the state of an Order
+type Status string
+
+// Order is an item with fields of most of the types and tags marshalddb
+// supports
+type Order struct {
+	ID        string            `dynamodb:"id"`
+	Customer  string            `json:"customer"`
+	Note      string            `dynamodb:"note,nullable"`
+	Token     string            `dynamodb:"token,binary,omitempty"`
+	Status    Status            `dynamodb:"status,alias=state"`
+	Count     int               `dynamodb:"count,string"`
+	Priority  int8              `dynamodb:"priority"`
+	Weight    uint32            `dynamodb:"weight,omitempty"`
+	Paid      bool              `dynamodb:"paid"`
+	Gift      bool              `dynamodb:"gift,omitempty"`
+	Total     float64           `dynamodb:"total"`
+	Discount  float32           `dynamodb:"discount,omitempty"`
+	Tags      []string          `dynamodb:"tags,list"`
+	Created   time.Time         `dynamodb:"created,unix"`
+	Updated   time.Time         `dynamodb:"updated,omitempty"`
+	Closed    time.Time         `dynamodb:"closed,unixmilli"`
+	Expires   time.Time         `dynamodb:"expires,omitempty,unixnano"`
+	Shipped   *time.Time        `dynamodb:"shipped,nullable,unixmilli"`
+	Items     []Item            `dynamodb:"items"`
+	Meta      map[string]string `dynamodb:"meta,omitempty"`
+	Balance   marshalddb.Number `dynamodb:"balance"`
+	Timeout   time.Duration     `dynamodb:"timeout,string"`
+	Retry     time.Duration     `dynamodb:"retry"`
+	Internal  string            `dynamodb:"-"`
+	CreatedBy string
+	secret    string
+}
+
+// Item is a line of an Order
+type Item struct {
+	SKU      string `dynamodb:"sku,required"`
+	Quantity uint   `dynamodb:"qty"`
+	Price    int64  `dynamodb:"price"`
+}
+
+// Receipt has no field that is converted
+type Receipt struct {
+	Internal string `dynamodb:"-"`
+	printed  bool
+}
+
+// Keys are the keys of a User, whose fields are promoted into it
+type Keys struct {
+	PK    string `dynamodb:"pk"`
+	SK    string `dynamodb:"sk"`
+	Owner string
+	Tag   string
+}
+
+// Audit records changes to a User, whose fields are promoted into it
+// unless the User's pointer to it is nil
+type Audit struct {
+	CreatedBy string `dynamodb:"created_by"`
+	Version   int    `dynamodb:"version"`
+	Owner     string
+	Kind      string `dynamodb:"Tag"`
+}
+
+// history is unexported, so marshalddb cannot allocate a nil pointer to it
+type history struct {
+	Revisions int `dynamodb:"revisions"`
+}
+
+// User has fields promoted from embedded structs. Its own Version hides
+// the Audit's, the Audit's tagged Kind hides the Keys' Tag and neither
+// Owner is converted.
+type User struct {
+	Keys
+	*Audit
+	*history
+	Name    string `dynamodb:"name"`
+	Version string `dynamodb:"version"`
+}
+
+// Cart has slices, maps and pointers of the types the generated code
+// converts directly, along with fields holding an Item
+type Cart struct {
+	Labels   []Status           `dynamodb:"labels"`
+	Scores   []float32          `dynamodb:"scores"`
+	Sizes    []uint16           `dynamodb:"sizes,list,omitempty"`
+	Counts   map[string]int     `dynamodb:"counts"`
+	Flags    map[string]bool    `dynamodb:"flags,nullable"`
+	Prices   map[Status]float64 `dynamodb:"prices"`
+	Quantity *int               `dynamodb:"qty,omitempty"`
+	Coupon   *string            `dynamodb:"coupon,nullable"`
+	Paid     *bool              `dynamodb:"paid"`
+	Credit   *marshalddb.Number `dynamodb:"credit,string"`
+	Wait     *time.Duration     `dynamodb:"wait"`
+	First    Item               `dynamodb:"first"`
+	Last     *Item              `dynamodb:"last,nullable"`
+	Saved    []Item             `dynamodb:"saved,set"`
+}
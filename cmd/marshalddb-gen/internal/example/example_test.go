@@ -0,0 +1,554 @@
+package example
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/jessejlt/marshalddb"
+)
+
+func TestGeneratedMarshalMatchesReflection(t *testing.T) {
+	t.Parallel()
+
+	shipped := time.Unix(1500000000, 0)
+	tests := []Order{
+		Order{},
+		Order{
+			ID:        "o-1",
+			Customer:  "c-1",
+			Note:      "leave at door",
+			Token:     "secret",
+			Status:    "open",
+			Count:     -3,
+			Priority:  -8,
+			Weight:    42,
+			Paid:      true,
+			Gift:      true,
+			Total:     12.5,
+			Discount:  0.1,
+			Tags:      []string{"b", "a"},
+			Created:   time.Unix(1400000000, 0),
+			Updated:   time.Date(2017, 7, 14, 2, 40, 0, 123456789, time.FixedZone("", 3600)),
+			Closed:    time.Unix(1500000000, 987654321),
+			Expires:   time.Unix(1600000000, 1),
+			Shipped:   &shipped,
+			Items:     []Item{Item{SKU: "s-1", Quantity: 2, Price: 300}},
+			Meta:      map[string]string{"k": "v"},
+			Balance:   marshalddb.Number("123456789012345678901234567890"),
+			Timeout:   90 * time.Minute,
+			Retry:     1500 * time.Millisecond,
+			Internal:  "skipped",
+			CreatedBy: "admin",
+			secret:    "skipped",
+		},
+		// DynamoDB cannot store these, so each is an error
+		Order{Total: math.Inf(1)},
+		Order{Discount: float32(math.NaN())},
+		Order{Total: 1e130},
+		Order{Expires: time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+
+		generated, generatedErr := marshalddb.Marshal(test)
+		reflective, reflectiveErr := marshalddb.Marshal(marshalddbOrder(test))
+
+		if !reflect.DeepEqual(generated, reflective) {
+			t.Errorf("generated %v, reflection %v", generated, reflective)
+		}
+		if (generatedErr == nil) != (reflectiveErr == nil) ||
+			(generatedErr != nil && generatedErr.Error() != reflectiveErr.Error()) {
+			t.Errorf("generated error %v, reflection error %v", generatedErr, reflectiveErr)
+		}
+	}
+}
+
+func TestGeneratedUnmarshalMatchesReflection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		av   *dynamodb.AttributeValue
+	}{
+		{
+			name: "null",
+			av: &dynamodb.AttributeValue{
+				NULL: aws.Bool(true),
+			},
+		},
+		{
+			name: "fields",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"id":       &dynamodb.AttributeValue{S: aws.String("o-1")},
+					"customer": &dynamodb.AttributeValue{S: aws.String("c-1")},
+					"note":     &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+					"token":    &dynamodb.AttributeValue{B: []byte("secret")},
+					"status":   &dynamodb.AttributeValue{S: aws.String("open")},
+					"count":    &dynamodb.AttributeValue{S: aws.String("-3")},
+					"priority": &dynamodb.AttributeValue{N: aws.String("-8")},
+					"weight":   &dynamodb.AttributeValue{N: aws.String("42")},
+					"paid":     &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+					"total":    &dynamodb.AttributeValue{N: aws.String("12.5")},
+					"discount": &dynamodb.AttributeValue{N: aws.String("0.1")},
+					"tags": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+						&dynamodb.AttributeValue{S: aws.String("b")},
+					}},
+					"created": &dynamodb.AttributeValue{N: aws.String("1400000000")},
+					"updated": &dynamodb.AttributeValue{S: aws.String("2017-07-14T02:40:00.123456789+01:00")},
+					"closed":  &dynamodb.AttributeValue{N: aws.String("-1500000000987")},
+					"expires": &dynamodb.AttributeValue{N: aws.String("1600000000000000001")},
+					"shipped": &dynamodb.AttributeValue{N: aws.String("1500000000000")},
+					"items": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+						&dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+							"sku":   &dynamodb.AttributeValue{S: aws.String("s-1")},
+							"qty":   &dynamodb.AttributeValue{N: aws.String("2")},
+							"price": &dynamodb.AttributeValue{N: aws.String("300")},
+						}},
+					}},
+					"balance":   &dynamodb.AttributeValue{N: aws.String("123456789012345678901234567890")},
+					"timeout":   &dynamodb.AttributeValue{S: aws.String("1h30m")},
+					"retry":     &dynamodb.AttributeValue{N: aws.String("1500000000")},
+					"CreatedBy": &dynamodb.AttributeValue{S: aws.String("admin")},
+					"unknown":   &dynamodb.AttributeValue{S: aws.String("ignored")},
+				},
+			},
+		},
+		{
+			name: "names matched by reflection",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"ID":        &dynamodb.AttributeValue{S: aws.String("o-1")},
+					"state":     &dynamodb.AttributeValue{S: aws.String("closed")},
+					"PRIORITY":  &dynamodb.AttributeValue{N: aws.String("7")},
+					"Retry":     &dynamodb.AttributeValue{N: aws.String("5")},
+					"createdby": &dynamodb.AttributeValue{S: aws.String("admin")},
+				},
+			},
+		},
+		{
+			name: "converted attributes",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"id":       &dynamodb.AttributeValue{N: aws.String("1")},
+					"count":    &dynamodb.AttributeValue{N: aws.String("3")},
+					"priority": &dynamodb.AttributeValue{S: aws.String("7")},
+					"paid":     &dynamodb.AttributeValue{N: aws.String("1")},
+					"token":    &dynamodb.AttributeValue{S: aws.String("secret")},
+					"total":    &dynamodb.AttributeValue{S: aws.String("1.5")},
+					"updated":  &dynamodb.AttributeValue{N: aws.String("1400000000")},
+					"closed":   &dynamodb.AttributeValue{S: aws.String("2017-07-14T02:40:00Z")},
+					"timeout":  &dynamodb.AttributeValue{N: aws.String("60000000000")},
+					"retry":    &dynamodb.AttributeValue{S: aws.String("2s")},
+				},
+			},
+		},
+		{
+			name: "invalid number",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"priority": &dynamodb.AttributeValue{N: aws.String("1e2")},
+				},
+			},
+		},
+		{
+			name: "out of range",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"priority": &dynamodb.AttributeValue{N: aws.String("300")},
+				},
+			},
+		},
+		{
+			name: "float out of range",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"discount": &dynamodb.AttributeValue{N: aws.String("1e40")},
+				},
+			},
+		},
+		{
+			name: "invalid time",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"updated": &dynamodb.AttributeValue{S: aws.String("yesterday")},
+				},
+			},
+		},
+		{
+			name: "invalid attribute matched by Go name",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"Priority": &dynamodb.AttributeValue{S: aws.String("high")},
+				},
+			},
+		},
+		{
+			name: "invalid nested attribute",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"items": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+						&dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+							"qty": &dynamodb.AttributeValue{N: aws.String("-2")},
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+
+		var generated Order
+		generatedErr := marshalddb.Unmarshal(test.av, &generated)
+
+		var reflective marshalddbOrder
+		reflectiveErr := marshalddb.Unmarshal(test.av, &reflective)
+
+		if !reflect.DeepEqual(generated, Order(reflective)) {
+			t.Errorf("%s: generated %+v, reflection %+v", test.name, generated, reflective)
+		}
+		if (generatedErr == nil) != (reflectiveErr == nil) ||
+			(generatedErr != nil && generatedErr.Error() != reflectiveErr.Error()) {
+			t.Errorf("%s: generated error %v, reflection error %v", test.name, generatedErr, reflectiveErr)
+		}
+	}
+
+	// a NULL note holds no value, so decoding it clears the field
+	generated := Order{Note: "stale"}
+	if err := marshalddb.Unmarshal(tests[1].av, &generated); err != nil {
+		t.Fatal(err)
+	}
+	if generated.Note != "" {
+		t.Errorf("Note: Expect=\"\", Have=%q", generated.Note)
+	}
+}
+
+func TestGeneratedEmbeddedMatchesReflection(t *testing.T) {
+	t.Parallel()
+
+	users := []User{
+		User{},
+		User{
+			Keys:    Keys{PK: "u-1", SK: "profile", Owner: "hidden", Tag: "hidden"},
+			Audit:   &Audit{CreatedBy: "admin", Version: 3, Owner: "hidden", Kind: "staff"},
+			history: &history{Revisions: 2},
+			Name:    "jane",
+			Version: "v2",
+		},
+	}
+
+	for _, test := range users {
+
+		generated, generatedErr := marshalddb.Marshal(test)
+		reflective, reflectiveErr := marshalddb.Marshal(marshalddbUser(test))
+
+		if !reflect.DeepEqual(generated, reflective) {
+			t.Errorf("generated %v, reflection %v", generated, reflective)
+		}
+		if (generatedErr == nil) != (reflectiveErr == nil) {
+			t.Errorf("generated error %v, reflection error %v", generatedErr, reflectiveErr)
+		}
+	}
+
+	s := func(s string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{S: aws.String(s)}
+	}
+	tests := []struct {
+		name string
+		to   User
+		av   *dynamodb.AttributeValue
+	}{
+		{
+			name: "promoted fields",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"pk":         s("u-1"),
+					"SK":         s("profile"),
+					"created_by": s("admin"),
+					"Tag":        s("staff"),
+					"version":    s("v2"),
+					"Owner":      s("ignored"),
+				},
+			},
+		},
+		{
+			name: "nil embedded pointer allocated",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"created_by": &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+				},
+			},
+		},
+		{
+			name: "nil unexported embedded pointer",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"revisions": &dynamodb.AttributeValue{N: aws.String("2")},
+				},
+			},
+		},
+		{
+			name: "unexported embedded pointer",
+			to:   User{history: &history{}},
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"revisions": &dynamodb.AttributeValue{N: aws.String("2")},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+
+		generated := test.to
+		if test.to.history != nil {
+			h := *test.to.history
+			generated.history = &h
+		}
+		generatedErr := marshalddb.Unmarshal(test.av, &generated)
+
+		reflective := marshalddbUser(test.to)
+		reflectiveErr := marshalddb.Unmarshal(test.av, &reflective)
+
+		if !reflect.DeepEqual(generated, User(reflective)) {
+			t.Errorf("%s: generated %+v, reflection %+v", test.name, generated, reflective)
+		}
+		if (generatedErr == nil) != (reflectiveErr == nil) ||
+			(generatedErr != nil && generatedErr.Error() != reflectiveErr.Error()) {
+			t.Errorf("%s: generated error %v, reflection error %v", test.name, generatedErr, reflectiveErr)
+		}
+	}
+}
+
+func TestGeneratedCartMatchesReflection(t *testing.T) {
+	t.Parallel()
+
+	qty, coupon, paid, credit, wait := 0, "", false, marshalddb.Number("-1.5"), time.Second
+	invalid := marshalddb.Number("1e200")
+	carts := []Cart{
+		Cart{},
+		Cart{
+			Labels:   []Status{"open", "open", ""},
+			Scores:   []float32{1.5, -2},
+			Sizes:    []uint16{},
+			Counts:   map[string]int{"a": 1, "b": 0},
+			Flags:    map[string]bool{},
+			Prices:   map[Status]float64{"open": 2.25},
+			Quantity: &qty,
+			Coupon:   &coupon,
+			Paid:     &paid,
+			Credit:   &credit,
+			Wait:     &wait,
+			First:    Item{SKU: "s-1", Quantity: 1},
+			Last:     &Item{SKU: "s-2"},
+			Saved:    []Item{},
+		},
+		Cart{
+			Labels: []Status{},
+			Sizes:  []uint16{8, 0},
+			Saved:  []Item{Item{Price: -1}},
+		},
+		// DynamoDB cannot store these, so each is an error
+		Cart{Scores: []float32{float32(math.Inf(-1))}},
+		Cart{Prices: map[Status]float64{"open": math.NaN()}},
+		Cart{Credit: &invalid},
+	}
+
+	for _, test := range carts {
+
+		generated, generatedErr := marshalddb.Marshal(test)
+		reflective, reflectiveErr := marshalddb.Marshal(marshalddbCart(test))
+
+		if !reflect.DeepEqual(generated, reflective) {
+			t.Errorf("generated %v, reflection %v", generated, reflective)
+		}
+		if (generatedErr == nil) != (reflectiveErr == nil) ||
+			(generatedErr != nil && generatedErr.Error() != reflectiveErr.Error()) {
+			t.Errorf("generated error %v, reflection error %v", generatedErr, reflectiveErr)
+		}
+	}
+
+	n := func(n string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{N: aws.String(n)}
+	}
+	s := func(s string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{S: aws.String(s)}
+	}
+	null := &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	item := &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"sku": s("s-1")}}
+
+	tests := []struct {
+		name string
+		// to returns the Cart decoded into, since maps are added to
+		to func() Cart
+		av *dynamodb.AttributeValue
+	}{
+		{
+			name: "fields",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"labels": &dynamodb.AttributeValue{SS: aws.StringSlice([]string{"open", "closed"})},
+					"scores": &dynamodb.AttributeValue{NS: aws.StringSlice([]string{"1.5", "-2"})},
+					"sizes":  &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{n("8"), nil}},
+					"counts": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"a": n("1"), "b": nil}},
+					"flags":  &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"x": &dynamodb.AttributeValue{BOOL: aws.Bool(true)}}},
+					"prices": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"open": n("2.25")}},
+					"qty":    n("0"),
+					"coupon": s("half"),
+					"paid":   &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+					"credit": s("-1.5"),
+					"wait":   n("1000000000"),
+					"first":  item,
+					"last":   item,
+					"saved":  &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{item, nil}},
+				},
+			},
+		},
+		{
+			name: "maps added to",
+			to: func() Cart {
+				return Cart{Counts: map[string]int{"a": 9, "c": 3}}
+			},
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"counts": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"a": n("1")}},
+				},
+			},
+		},
+		{
+			name: "null pointers",
+			to: func() Cart {
+				qty, coupon := 1, "half"
+				return Cart{Quantity: &qty, Coupon: &coupon, Last: &Item{SKU: "s-1"}}
+			},
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"qty":    null,
+					"coupon": null,
+					"last":   null,
+				},
+			},
+		},
+		{
+			name: "converted attributes",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"labels": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{n("1"), null}},
+					"scores": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{s("1.5")}},
+					"sizes":  &dynamodb.AttributeValue{NS: aws.StringSlice([]string{"8"})},
+					"counts": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"a": s("1"), "b": null}},
+					"qty":    s("3"),
+					"credit": n("2"),
+					"wait":   s("1m"),
+					"first":  null,
+				},
+			},
+		},
+		{
+			name: "invalid element",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"sizes": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{n("8"), n("70000")}},
+				},
+			},
+		},
+		{
+			name: "invalid map element",
+			to: func() Cart {
+				return Cart{Counts: map[string]int{"c": 3}}
+			},
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"counts": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"a": n("x")}},
+				},
+			},
+		},
+		{
+			name: "invalid nested attribute",
+			av: &dynamodb.AttributeValue{
+				M: map[string]*dynamodb.AttributeValue{
+					"last": &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"qty": n("-1")}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+
+		var generated, reflective Cart
+		if test.to != nil {
+			generated, reflective = test.to(), test.to()
+		}
+		generatedErr := marshalddb.Unmarshal(test.av, &generated)
+		reflectiveErr := marshalddb.Unmarshal(test.av, (*marshalddbCart)(&reflective))
+
+		if !reflect.DeepEqual(generated, reflective) {
+			t.Errorf("%s: generated %+v, reflection %+v", test.name, generated, reflective)
+		}
+		if (generatedErr == nil) != (reflectiveErr == nil) ||
+			(generatedErr != nil && generatedErr.Error() != reflectiveErr.Error()) {
+			t.Errorf("%s: generated error %v, reflection error %v", test.name, generatedErr, reflectiveErr)
+		}
+	}
+}
+
+func TestOptionsBypassGeneratedMethods(t *testing.T) {
+	t.Parallel()
+
+	d := marshalddb.NewDecoder(marshalddb.EnforceRequired(), marshalddb.DisallowUnknownFields(), marshalddb.StrictTypes())
+	tests := []struct {
+		name   string
+		item   map[string]*dynamodb.AttributeValue
+		expect error
+	}{
+		{
+			name: "missing required field",
+			item: map[string]*dynamodb.AttributeValue{
+				"qty": &dynamodb.AttributeValue{N: aws.String("2")},
+			},
+			expect: marshalddb.ErrMissingRequiredField,
+		},
+		{
+			name: "unknown field",
+			item: map[string]*dynamodb.AttributeValue{
+				"sku":   &dynamodb.AttributeValue{S: aws.String("s-1")},
+				"color": &dynamodb.AttributeValue{S: aws.String("red")},
+			},
+			expect: marshalddb.ErrUnknownField,
+		},
+		{
+			name: "string for number",
+			item: map[string]*dynamodb.AttributeValue{
+				"sku": &dynamodb.AttributeValue{S: aws.String("s-1")},
+				"qty": &dynamodb.AttributeValue{S: aws.String("2")},
+			},
+			expect: marshalddb.ErrInvalidConversion,
+		},
+	}
+
+	for _, test := range tests {
+
+		var item Item
+		if err := d.Unmarshal(&dynamodb.AttributeValue{M: test.item}, &item); !errors.Is(err, test.expect) {
+			t.Errorf("%s: Expect=%v, Have=%v", test.name, test.expect, err)
+		}
+	}
+
+	e := marshalddb.NewEncoder(marshalddb.EncodeBoolsAs(marshalddb.TypeNumber), marshalddb.EncodeNaming(marshalddb.SnakeCase))
+	av, err := e.Marshal(Order{Paid: true, CreatedBy: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid := av.M["paid"]; paid == nil || aws.StringValue(paid.N) != "1" {
+		t.Errorf("paid: Expect=N 1, Have=%v", paid)
+	}
+	if by := av.M["created_by"]; by == nil || aws.StringValue(by.S) != "admin" {
+		t.Errorf("created_by: Expect=S admin, Have=%v", by)
+	}
+}
@@ -0,0 +1,83 @@
+// Command marshalddb-gen generates MarshalDynamoDBAttributeValue and
+// UnmarshalDynamoDBAttributeValue methods for struct types, which
+// marshalddb then uses in place of reflection.
+//
+// Usage:
+//
+//	marshalddb-gen -type T[,T...] [-output file] [dir]
+//
+// It is typically run by a go:generate directive beside the types:
+//
+//	//go:generate marshalddb-gen -type Order
+//
+// The generated code reads the same `dynamodb` and `json` struct tags as
+// marshalddb. Fields holding strings, bools, integers, floats,
+// marshalddb.Number, time.Time and time.Duration, or pointers to them, are
+// converted by the generated code itself, as are slices of strings and
+// numbers, maps from strings to those and bools, and structs whose methods
+// are also generated, which are converted by calling them. Any other field
+// is converted by a marshalddb.Field, created once for each field, which
+// also reports the errors of values that DynamoDB cannot store.
+// Attribute names are matched to fields as marshalddb matches them,
+// without reflection. The generated methods follow the default rules, so
+// an Encoder or Decoder created with any options skips them and converts
+// the type by reflection.
+//
+// The fields of embedded structs are promoted as marshalddb promotes them,
+// and nil embedded pointers are allocated when their fields are decoded.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; must be set")
+	output    = flag.String("output", "", "output file name; default <dir>/<type>_marshalddb.go")
+)
+
+func usage() {
+
+	fmt.Fprintf(os.Stderr, "Usage of marshalddb-gen:\n")
+	fmt.Fprintf(os.Stderr, "\tmarshalddb-gen -type T[,T...] [-output file] [dir]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+
+	log.SetFlags(0)
+	log.SetPrefix("marshalddb-gen: ")
+
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	types := strings.Split(*typeNames, ",")
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(types[0])+"_marshalddb.go")
+	}
+
+	src, err := generate(dir, types, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
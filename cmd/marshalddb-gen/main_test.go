@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerate checks that the generated code in internal/example, whose
+// tests compare it against reflection, is up to date
+func TestGenerate(t *testing.T) {
+
+	dir := filepath.Join("internal", "example")
+	out := filepath.Join(dir, "order_marshalddb.go")
+
+	got, err := generate(dir, []string{"Order", "Item", "Receipt", "User", "Cart"}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date, run go generate ./%s", out, dir)
+	}
+}
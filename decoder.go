@@ -0,0 +1,190 @@
+package marshalddb
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// A Decoder converts AttributeValues into Go values. A Decoder is safe
+// for concurrent use once created.
+type Decoder struct {
+	useNumber             bool
+	disallowUnknownFields bool
+	enforceRequired       bool
+	strictTypes           bool
+	collectErrors         bool
+	numbers               Type
+	bools                 Type
+	tagKey                string
+	naming                NamingStrategy
+	decoders              map[reflect.Type]DecodeFunc
+	fields                *fieldCache
+	// configured is set when the Decoder has any options, which the
+	// methods written by marshalddb-gen do not follow
+	configured bool
+}
+
+// DecodeFunc sets the value pointed to by v from av
+type DecodeFunc func(av *dynamodb.AttributeValue, v interface{}) error
+
+// A DecoderOption configures a Decoder
+type DecoderOption func(*Decoder)
+
+// UseNumber decodes N attributes held by an interface{} into a Number
+// rather than a float64, keeping all of their precision.
+func UseNumber() DecoderOption {
+
+	return func(d *Decoder) {
+		d.useNumber = true
+	}
+}
+
+// DisallowUnknownFields fails decoding with ErrUnknownField when an item
+// holds an attribute that no field of the target struct is read from.
+func DisallowUnknownFields() DecoderOption {
+
+	return func(d *Decoder) {
+		d.disallowUnknownFields = true
+	}
+}
+
+// EnforceRequired fails decoding with ErrMissingRequiredField when an item
+// does not hold an attribute for a field tagged `dynamodb:",required"`,
+// or holds it as NULL.
+func EnforceRequired() DecoderOption {
+
+	return func(d *Decoder) {
+		d.enforceRequired = true
+	}
+}
+
+// StrictTypes fails decoding with ErrInvalidConversion rather than
+// converting an attribute into a field of another type, such as a BOOL
+// into an int or an N into a string. Numbers and bools stored as strings
+// are still decoded from fields tagged with the string option. A time.Time
+// is decoded from an S attribute, or an N attribute if tagged with a unix
+// option, and a time.Duration from an N attribute, or an S attribute if
+// tagged with the string option. Types decoded by a DecodeFunc, an
+// Unmarshaler, an encoding.TextUnmarshaler or an
+// encoding.BinaryUnmarshaler decide for themselves which attributes they
+// accept.
+func StrictTypes() DecoderOption {
+
+	return func(d *Decoder) {
+		d.strictTypes = true
+	}
+}
+
+// DecodeNumbersAs accepts numbers stored as attributes of type typ even
+// with StrictTypes, where typ is TypeString for numbers stored as though
+// every number were tagged with the string option. It panics if typ is
+// neither TypeNumber nor TypeString.
+func DecodeNumbersAs(typ Type) DecoderOption {
+
+	mustBeFormat("DecodeNumbersAs", typ, TypeNumber, TypeString)
+	return func(d *Decoder) {
+		d.numbers = typ
+	}
+}
+
+// DecodeBoolsAs accepts bools stored as attributes of type typ even with
+// StrictTypes, where typ is TypeNumber for bools stored as "1" and "0",
+// or TypeString for bools stored as "true" and "false". It panics if typ
+// is any other Type than these or TypeBool.
+func DecodeBoolsAs(typ Type) DecoderOption {
+
+	mustBeFormat("DecodeBoolsAs", typ, TypeBool, TypeNumber, TypeString)
+	return func(d *Decoder) {
+		d.bools = typ
+	}
+}
+
+// DecodeTagKey reads the names and options of struct fields from the
+// struct tag key, rather than from their `dynamodb` tag. Fields without
+// that tag are still named by their `json` tag.
+func DecodeTagKey(key string) DecoderOption {
+
+	return func(d *Decoder) {
+		d.tagKey = key
+	}
+}
+
+// DecodeNaming matches attributes to struct fields without a name in
+// their struct tags by naming, rather than by their Go names.
+func DecodeNaming(naming NamingStrategy) DecoderOption {
+
+	return func(d *Decoder) {
+		d.naming = naming
+	}
+}
+
+// DecodeTypeWith sets values of type t with fn, rather than by the rules
+// of the Decoder. fn is passed a pointer to a value of type t, which
+// should not be a pointer type since pointers are followed to the values
+// they hold.
+func DecodeTypeWith(t reflect.Type, fn DecodeFunc) DecoderOption {
+
+	return func(d *Decoder) {
+		if d.decoders == nil {
+			d.decoders = make(map[reflect.Type]DecodeFunc)
+		}
+		d.decoders[t] = fn
+	}
+}
+
+// CollectDecodeErrors keeps decoding after an attribute fails to decode,
+// setting every field that could be decoded and returning an Errors
+// listing each attribute that could not.
+func CollectDecodeErrors() DecoderOption {
+
+	return func(d *Decoder) {
+		d.collectErrors = true
+	}
+}
+
+// defaultDecoder backs the package level decoding functions
+var defaultDecoder = NewDecoder()
+
+// NewDecoder returns a Decoder configured by opts
+func NewDecoder(opts ...DecoderOption) *Decoder {
+
+	d := new(Decoder)
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.fields = fieldsFor(d.tagKey, d.naming)
+	d.configured = len(opts) != 0
+	return d
+}
+
+// Unmarshal sets the value pointed to by v from a single AttributeValue
+func (d *Decoder) Unmarshal(av *dynamodb.AttributeValue, v interface{}) error {
+
+	to := reflect.ValueOf(v)
+	if to.Kind() != reflect.Ptr || to.IsNil() {
+		return ErrNilTarget
+	}
+
+	if av == nil {
+		return nil
+	}
+
+	toEl := to.Elem()
+	if err := d.setAttribute(av, &toEl, fieldTag{}); err != nil {
+		return decodeError(err, "", av, toEl.Type())
+	}
+	return nil
+}
+
+// ConvertFromAttributes maps an item into the struct or map pointed to by v
+func (d *Decoder) ConvertFromAttributes(item map[string]*dynamodb.AttributeValue, v interface{}) error {
+
+	// a nil item, as GetItem returns when nothing is found, holds no
+	// attributes for a struct, so its required fields are still missing
+	if t := reflect.TypeOf(v); item == nil && t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		item = map[string]*dynamodb.AttributeValue{}
+	}
+
+	return d.Unmarshal(&dynamodb.AttributeValue{M: item}, v)
+}
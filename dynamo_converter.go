@@ -1,220 +1,244 @@
 package marshalddb
 
 import (
-	"math"
 	"reflect"
 	"strconv"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
-// ConvertFromAttributes maps a DB returned map[string]*dynamodb.AttributeValue into a specified struct.
-func ConvertFromAttributes(item map[string]*dynamodb.AttributeValue, v interface{}) error {
+// Marshal converts any Go value into a single AttributeValue, such as a
+// key condition or update expression value. Strings become S attributes,
+// numbers N, bools BOOL, slices of strings and numbers SS and NS sets,
+// other slices and arrays L, and structs and maps M. Values that DynamoDB
+// cannot store, such as nil pointers and empty strings, become NULL.
+func Marshal(v interface{}) (*dynamodb.AttributeValue, error) {
 
-	to := reflect.ValueOf(v)
-	if to.Kind() != reflect.Ptr || to.IsNil() {
-		return ErrNilTarget
-	}
-	toEl := to.Elem()
-
-	for key, attrValue := range item {
-
-		attrValueV := reflect.ValueOf(attrValue)
-		// This check is questionable since the compiler guarantees it
-		// since we're checking that `attrValueV is a *dynamodb.AttributeValue`,
-		// But for correctness the check is valid. Possibly remove for *performance*
-		if attrValueV.Kind() == reflect.Ptr && !attrValueV.IsNil() {
-
-			// At this point we have a `*dynamodb.AttributeValue` and
-			// need to iterate through its elements as described by
-			// https://github.com/awslabs/aws-sdk-go/blob/master/service/dynamodb/api.go#L726
-			// and find the first non-nil field, which will then become
-			// our target for conversion
-			attrValueEl := attrValueV.Elem()
-			numFields := attrValueEl.NumField()
-			for i := 0; i < numFields; i++ {
-
-				field := attrValueEl.Field(i)
-				fk := field.Kind()
-				if fk == reflect.Struct ||
-					(fk == reflect.Ptr && field.IsNil()) ||
-					(fk == reflect.Slice && field.IsNil()) ||
-					(fk == reflect.Map && field.Len() == 0) {
-					continue
-				}
+	return defaultEncoder.Marshal(v)
+}
 
-				// `field` is our target for conversion, now we
-				// need to find a field by the same name in our
-				// target struct and then make sure we can set
-				// a value on said field
+// Unmarshal sets the value pointed to by v from a single AttributeValue,
+// following the same rules as Marshal.
+func Unmarshal(av *dynamodb.AttributeValue, v interface{}) error {
 
-				// toField := toEl.FieldByName(key)
-				toField := fieldByName(toEl, key)
-				if toField.CanSet() {
+	return defaultDecoder.Unmarshal(av, v)
+}
 
-					var fieldEl reflect.Value
-					if fk == reflect.Slice || fk == reflect.Map {
+// ConvertFromAttributes maps a DB returned map[string]*dynamodb.AttributeValue into a specified struct,
+// or into a map such as map[string]interface{} for items without a struct definition.
+// M attributes are decoded recursively into nested structs, maps keyed by
+// strings and interface{} values.
+func ConvertFromAttributes(item map[string]*dynamodb.AttributeValue, v interface{}) error {
 
-						fieldEl = field
-					} else {
+	return defaultDecoder.ConvertFromAttributes(item, v)
+}
 
-						fieldEl = field.Elem()
-					}
+// setStructFields sets each AttributeValue within item onto the struct
+// field of toEl with a matching name
+func (d *Decoder) setStructFields(item map[string]*dynamodb.AttributeValue, toEl reflect.Value) error {
 
-					typeOfAttrValue := attrValueEl.Type()
-					attrValueName := typeOfAttrValue.Field(i).Name
+	var errs Errors
 
-					err := setFieldVal(
-						attrValueName,
-						fieldEl,
-						&toField,
-						typeOfAttrValue,
-					)
-					if err != nil {
-						return err
-					}
+	// the attribute names of the fields that were set
+	set := make(map[string]bool, len(item))
 
-				}
+	for _, key := range d.itemKeys(item) {
 
-				break
-			}
+		attrValue := item[key]
+		if attrValue == nil {
+			continue
 		}
-	}
 
-	return nil
-}
+		// find a field by the same name in our target struct and
+		// then make sure we can set a value on said field
+		toField, f := d.fields.fieldByName(toEl, key)
+		if !toField.CanSet() {
 
-// ConvertToAttributes converts a struct into a dynamodb representation
-func ConvertToAttributes(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+			// a field was found, but is promoted through a nil pointer
+			// to an unexported struct, as encoding/json reports
+			if f.index != nil {
+				err := decodeError(ErrUnexportedEmbeddedPointer, key, attrValue, f.typ)
+				if !d.collectErrors {
+					return err
+				}
+				errs = errs.add(err)
+				continue
+			}
 
-	to := make(map[string]*dynamodb.AttributeValue)
-	ev := reflect.ValueOf(v)
-	if ev.Kind() == reflect.Ptr || ev.Kind() == reflect.Interface {
-		ev = ev.Elem()
-	}
-	et := ev.Type()
+			if d.disallowUnknownFields {
+				err := decodeError(ErrUnknownField, key, attrValue, nil)
+				if !d.collectErrors {
+					return err
+				}
+				errs = errs.add(err)
+			}
+			continue
+		}
 
-	switch ev.Kind() {
+		// funcs given to DecodeTypeWith are only looked up by setAttribute
+		decode := f.decode
+		if len(d.decoders) != 0 {
+			decode = (*Decoder).setAttribute
+		}
 
-	case reflect.Struct:
+		tag := f.fieldTag
+		if err := decode(d, attrValue, &toField, tag); err != nil {
+			err = decodeError(err, key, attrValue, toField.Type())
+			if !d.collectErrors {
+				return err
+			}
+			errs = errs.add(err)
+		}
+		if attrValue.NULL == nil || !*attrValue.NULL {
+			set[tag.name] = true
+		}
+	}
 
-		for i := 0; i < ev.NumField(); i++ {
+	if d.enforceRequired {
 
-			f := ev.Field(i)
-			fk := f.Kind()
-			if fk == reflect.Ptr {
-				f = f.Elem()
-				fk = f.Kind()
-			}
+		for _, f := range d.fields.cachedTypeFields(toEl.Type()).list {
 
-			if !f.IsValid() || reflect.Zero(f.Type()) == f {
-				continue
+			if f.required && !set[f.name] {
+				err := decodeError(ErrMissingRequiredField, f.name, nil, toEl.Type().FieldByIndex(f.index).Type)
+				if !d.collectErrors {
+					return err
+				}
+				errs = errs.add(err)
 			}
+		}
+	}
 
-			fieldName := et.Field(i).Name
-			if tn := et.Field(i).Tag.Get("json"); tn != "" {
-				// prefer to use the struct tag name over the field name
-				fieldName = tn
-			}
+	return errs.err()
+}
 
-			switch fk {
+// setAttribute sets the first non-nil value of attr onto toField, using
+// the options of the struct field tag toField was read from
+func (d *Decoder) setAttribute(attr *dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error {
 
-			case reflect.String:
-				// Dynamo does not allow setting empty strings
-				// http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_PutItem.html
-				if f.String() != "" {
+	if toField.Kind() == reflect.Ptr {
 
-					to[fieldName] = &dynamodb.AttributeValue{
-						S: aws.String(f.String()),
-					}
-				}
+		// NULL leaves a pointer nil
+		if attr.NULL != nil && *attr.NULL {
+			toField.Set(reflect.Zero(toField.Type()))
+			return nil
+		}
 
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				to[fieldName] = &dynamodb.AttributeValue{
-					N: aws.String(strconv.FormatInt(f.Int(), 10)),
-				}
+		// otherwise the pointer is allocated if needed and its element
+		// set, as is a pointer to a pointer
+		if toField.IsNil() {
+			toField.Set(reflect.New(toField.Type().Elem()))
+		}
+		el := toField.Elem()
+		return d.setAttribute(attr, &el, tag)
+	}
 
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-				to[fieldName] = &dynamodb.AttributeValue{
-					N: aws.String(strconv.FormatUint(f.Uint(), 10)),
-				}
+	if fn, ok := d.decoders[toField.Type()]; ok {
 
-			case reflect.Float32, reflect.Float64:
-				ff := f.Float()
-				if math.IsInf(ff, 0) || math.IsNaN(ff) {
-					return to, ErrInvalidFloat
-				}
-				to[fieldName] = &dynamodb.AttributeValue{
-					N: aws.String(strconv.FormatFloat(ff, 'g', -1, f.Type().Bits())),
-				}
+		v := reflect.New(toField.Type())
+		if err := fn(attr, v.Interface()); err != nil {
+			return err
+		}
+		toField.Set(v.Elem())
+		return nil
+	}
 
-			case reflect.Bool:
-				to[fieldName] = &dynamodb.AttributeValue{
-					BOOL: aws.Bool(f.Bool()),
-				}
+	switch toField.Type() {
 
-			case reflect.Slice, reflect.Array:
+	case timeType:
+		if d.strictTypes && !d.strictlyConvertible(AttributeType(attr), timeType, tag) {
+			return ErrInvalidConversion
+		}
+		return setTime(attr, toField, tag)
 
-				if f.Len() == 0 {
-					continue
-				}
+	case durationType:
+		if d.strictTypes && !d.strictlyConvertible(AttributeType(attr), durationType, tag) {
+			return ErrInvalidConversion
+		}
+		return setDuration(attr, toField)
 
-				switch f.Index(0).Kind() {
+	case bigIntType, bigFloatType, bigRatType:
+		if d.strictTypes && !d.strictlyConvertible(AttributeType(attr), toField.Type(), tag) {
+			return ErrInvalidConversion
+		}
+		return setBig(attr, toField)
+	}
 
-				case reflect.String:
-					to[fieldName] = createSS(f)
+	if ok, err := setUnmarshaled(attr, *toField, d.configured); ok {
+		return err
+	}
 
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
-					reflect.Float32, reflect.Float64,
-					reflect.Bool:
-					fi, err := createNS(f)
-					if err != nil {
-						return to, err
-					}
-					to[fieldName] = fi
+	// an empty interface receives the attribute's natural Go representation
+	if toField.Kind() == reflect.Interface && toField.NumMethod() == 0 {
 
-				case reflect.Slice:
-					to[fieldName] = createBS(f)
+		i, err := d.attributeInterface(attr)
+		if err != nil {
+			return err
+		}
+		if i == nil {
+			toField.Set(reflect.Zero(toField.Type()))
+		} else {
+			toField.Set(reflect.ValueOf(i))
+		}
+		return nil
+	}
 
-				default:
-					return to, ErrConversionNotSupported
+	return d.setValue(attr, toField, tag)
+}
 
-				}
+// setValue sets toField by its kind from attr, once any pointers have been
+// followed and none of the types converted specially apply
+func (d *Decoder) setValue(attr *dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error {
 
-			case reflect.Struct, reflect.Map:
+	typ := AttributeType(attr)
+	if typ == TypeNone {
+		return nil
+	}
 
-				fi, err := createSJSON(f)
-				if err != nil {
-					return to, err
-				}
-				to[fieldName] = fi
+	if d.strictTypes && !d.strictlyConvertible(typ, toField.Type(), tag) {
+		return ErrInvalidConversion
+	}
 
-			default:
-				return to, ErrConversionNotSupported
-			}
-		}
+	return d.setFieldVal(
+		attr,
+		typ,
+		toField,
+		tag,
+	)
+}
 
-	default:
-		return to, ErrConversionNotSupported
-	}
+// ConvertToAttributes converts a struct into a dynamodb representation.
+// Nested structs and maps are converted into M attributes unless the
+// field is tagged `dynamodb:",json"`, in which case they are stored as
+// a JSON encoded S attribute.
+//
+// Zero values, such as 0 and false, are written unless the field is tagged
+// omitempty. Nil pointers, slices and maps are never written, and nor are
+// empty strings and sets, since DynamoDB does not allow them.
+func ConvertToAttributes(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
 
-	return to, nil
+	return defaultEncoder.ConvertToAttributes(v)
 }
 
-func setFieldVal(attributeValueName string, fieldEl reflect.Value, toField *reflect.Value, typeOfAttrValue reflect.Type) error {
+func (d *Decoder) setFieldVal(attr *dynamodb.AttributeValue, typ Type, toField *reflect.Value, tag fieldTag) error {
 
 	var err error
 
-	switch attributeValueName {
+	switch typ {
+
+	case TypeString:
+		err = setFieldWithKind(toField.Kind(), *attr.S, toField)
 
-	case "S", "N":
-		err = setFieldWithKind(toField.Kind(), fieldEl, toField)
+	case TypeNumber:
+		err = setFieldWithKind(toField.Kind(), *attr.N, toField)
 
-	case "BOOL", "NULL":
+	case TypeNull:
+		// NULL holds no value, such as a nullable field's empty string or
+		// a nil element within an L, so any field is left at its zero value
+		toField.Set(reflect.Zero(toField.Type()))
 
-		fromVal := fieldEl.Bool()
+	case TypeBool:
+
+		fromVal := *attr.BOOL
 		switch toField.Kind() {
 
 		case reflect.String:
@@ -248,125 +272,124 @@ func setFieldVal(attributeValueName string, fieldEl reflect.Value, toField *refl
 			err = ErrInvalidConversion
 		}
 
-	case "B":
+	case TypeBinary:
 
-		fromVal := fieldEl.Bytes()
+		fromVal := attr.B
 		switch toField.Kind() {
 
 		case reflect.String:
 			toField.SetString(string(fromVal))
 
 		case reflect.Slice:
+			if toField.Type().Elem().Kind() != reflect.Uint8 {
+				err = ErrInvalidConversion
+				break
+			}
 			toField.SetBytes(fromVal)
 
+		case reflect.Array:
+			// a fixed size array must be filled exactly
+			if toField.Type().Elem().Kind() != reflect.Uint8 || toField.Len() != len(fromVal) {
+				err = ErrInvalidConversion
+				break
+			}
+			for i, b := range fromVal {
+				toField.Index(i).SetUint(uint64(b))
+			}
+
 		default:
 			err = ErrInvalidConversion
 
 		}
 
-	case "SS", "NS":
-
-		fromLen := fieldEl.Len()
-		arr := reflect.MakeSlice(toField.Type(), fromLen, fromLen)
-
-		switch toField.Type().Elem().Kind() {
-
-		case reflect.String:
-
-			for i := 0; i < fromLen; i++ {
-				arr.Index(i).SetString(fieldEl.Index(i).Elem().String())
-			}
-			toField.Set(arr)
-
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-
-			for i := 0; i < fromLen; i++ {
-
-				toFieldAtIndex := arr.Index(i)
-				if err := setInt(fieldEl.Index(i).Elem(), &toFieldAtIndex); err != nil {
-					return err
-				}
-			}
-			toField.Set(arr)
+	case TypeStringSet, TypeNumberSet, TypeBinarySet:
+		// sets are decoded member by member, as an L of their members
+		// or into the keys of a map set
+		members := setMembers(attr)
+		if toField.Kind() == reflect.Map {
+			err = d.setMapSet(members, toField, tag)
+			break
+		}
+		err = d.setList(members, toField, tag)
 
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	case TypeList:
+		err = d.setList(attr.L, toField, tag)
 
-			for i := 0; i < fromLen; i++ {
+	case TypeMap:
+		err = d.setMap(attr.M, toField, tag)
 
-				toFieldAtIndex := arr.Index(i)
-				if err := setUint(fieldEl.Index(i).Elem(), &toFieldAtIndex); err != nil {
-					return err
-				}
-			}
-			toField.Set(arr)
+	default:
+		return ErrConversionNotSupported
+	}
 
-		case reflect.Float32, reflect.Float64:
+	return err
+}
 
-			for i := 0; i < fromLen; i++ {
+// strictlyConvertible reports whether an attribute of type typ holds the
+// same kind of value as type t, rather than one that can be converted,
+// allowing for numbers and bools stored as the Decoder expects
+func (d *Decoder) strictlyConvertible(typ Type, t reflect.Type, tag fieldTag) bool {
 
-				toFieldAtIndex := arr.Index(i)
-				if err := setFloat(fieldEl.Index(i).Elem(), &toFieldAtIndex); err != nil {
-					return err
-				}
-			}
-			toField.Set(arr)
+	k := t.Kind()
+	isNumber := t == numberType || (isNumericKind(k) && k != reflect.Bool)
+	numberAsString := tag.asString || d.numbers == TypeString
 
-		default:
-			return ErrInvalidConversion
+	// times, durations and big numbers are only decoded from the type of
+	// attribute they are encoded as
+	switch t {
 
+	case timeType:
+		if tag.unixTime != 0 {
+			return typ == TypeNumber || typ == TypeNull
 		}
+		return typ == TypeString || typ == TypeNull
 
-	case "L":
-
-		fromLen := fieldEl.Len()
-		arr := reflect.MakeSlice(toField.Type(), fromLen, fromLen)
-
-		// iterate through the slice
-		for i := 0; i < fromLen; i++ {
+	case durationType:
+		if numberAsString && typ == TypeString {
+			return true
+		}
+		return typ == TypeNumber || typ == TypeNull
 
-			_, v := extractAttributeFromValue(fieldEl.Index(i).Elem())
-			toFieldAtIndex := arr.Index(i)
-			if err = setFieldWithKind(v.Kind(), v, &toFieldAtIndex); err != nil {
-				break
-			}
+	case bigIntType, bigFloatType, bigRatType:
+		if numberAsString && typ == TypeString {
+			return true
 		}
-		toField.Set(arr)
+		return typ == TypeNumber || typ == TypeNull
+	}
 
-	case "BS":
+	switch typ {
 
-		// Only covering the case of [][]byte to [][]byte
-		if toField.Type().String() != "[][]uint8" {
-			return ErrConversionNotSupported
+	case TypeString:
+		if tag.json || tag.asString {
+			return true
+		}
+		if (d.numbers == TypeString && isNumber) || (d.bools == TypeString && k == reflect.Bool) {
+			return true
 		}
+		return k == reflect.String && t != numberType
 
-		fromLen := fieldEl.Len()
-		arr := reflect.MakeSlice(toField.Type(), fromLen, fromLen)
-		for i := 0; i < fromLen; i++ {
+	case TypeNumber:
+		return isNumber || (d.bools == TypeNumber && k == reflect.Bool)
 
-			f := fieldEl.Index(i)
-			if f.Kind() == reflect.Ptr {
-				f = f.Elem()
-			}
+	case TypeBool:
+		return k == reflect.Bool
 
-			fl := f.Len()
-			tarr := reflect.MakeSlice(arr.Index(i).Type(), fl, fl)
-			for j := 0; j < fl; j++ {
+	case TypeNull:
+		// NULL holds no value of any type
+		return true
 
-				toFieldAtIndex := tarr.Index(j)
-				if err = setFieldWithKind(toFieldAtIndex.Kind(), f.Index(j), &toFieldAtIndex); err != nil {
-					break
-				}
-			}
-			arr.Index(i).Set(tarr)
+	case TypeBinary:
+		if tag.binary && k == reflect.String {
+			return true
 		}
-		toField.Set(arr)
+		return (k == reflect.Slice || k == reflect.Array) && t.Elem().Kind() == reflect.Uint8
 
-	case "M":
-		return ErrConversionNotSupported
+	case TypeStringSet, TypeNumberSet, TypeBinarySet, TypeList:
+		return k == reflect.Slice || k == reflect.Array || k == reflect.Map
 
-	default:
-		return ErrConversionNotSupported
+	case TypeMap:
+		return k == reflect.Struct || k == reflect.Map
 	}
 
-	return err
+	return false
 }
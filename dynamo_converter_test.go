@@ -1,50 +1,52 @@
 package marshalddb
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"math/big"
+	"net"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
-func TestExtractAttribute(t *testing.T) {
+func TestAttributeType(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		Attr       *dynamodb.AttributeValue
-		ExpectName string
-		ExpectKind reflect.Kind
+		Attr   *dynamodb.AttributeValue
+		Expect Type
 	}{
 		{
 			Attr: &dynamodb.AttributeValue{
 				B: []byte(`Some Bytes`),
 			},
-			ExpectName: "B",
-			ExpectKind: reflect.Slice,
+			Expect: TypeBinary,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				BOOL: aws.Bool(true),
 			},
-			ExpectName: "BOOL",
-			ExpectKind: reflect.Bool,
+			Expect: TypeBool,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				BS: make([][]byte, 1),
 			},
-			ExpectName: "BS",
-			ExpectKind: reflect.Slice,
+			Expect: TypeBinarySet,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				L: make([]*dynamodb.AttributeValue, 1),
 			},
-			ExpectName: "L",
-			ExpectKind: reflect.Slice,
+			Expect: TypeList,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
@@ -54,56 +56,73 @@ func TestExtractAttribute(t *testing.T) {
 					},
 				},
 			},
-			ExpectName: "M",
-			ExpectKind: reflect.Map,
+			Expect: TypeMap,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				N: aws.String("-1"),
 			},
-			ExpectName: "N",
-			ExpectKind: reflect.String,
+			Expect: TypeNumber,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				NS: []*string{aws.String("-1")},
 			},
-			ExpectName: "NS",
-			ExpectKind: reflect.Slice,
+			Expect: TypeNumberSet,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				NULL: aws.Bool(false),
 			},
-			ExpectName: "NULL",
-			ExpectKind: reflect.Bool,
+			Expect: TypeNull,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				S: aws.String("StringString"),
 			},
-			ExpectName: "S",
-			ExpectKind: reflect.String,
+			Expect: TypeString,
 		},
 		{
 			Attr: &dynamodb.AttributeValue{
 				SS: []*string{aws.String("StringString")},
 			},
-			ExpectName: "SS",
-			ExpectKind: reflect.Slice,
+			Expect: TypeStringSet,
+		},
+		{
+			Attr: &dynamodb.AttributeValue{
+				L: []*dynamodb.AttributeValue{},
+			},
+			Expect: TypeList,
+		},
+		{
+			Attr: &dynamodb.AttributeValue{
+				SS: []*string{},
+			},
+			Expect: TypeNone,
+		},
+		{
+			Attr:   &dynamodb.AttributeValue{},
+			Expect: TypeNone,
+		},
+		{
+			Attr:   nil,
+			Expect: TypeNone,
 		},
 	}
 
 	for _, tt := range tests {
 
-		name, val := extractAttribute(tt.Attr)
-		if name != tt.ExpectName {
-			t.Errorf("Name: Expect=%s, Recieved=%s", tt.ExpectName, name)
-		}
-		if val.Kind() != tt.ExpectKind {
-			t.Errorf("Kind: Expect=%v, Recieved=%v", tt.ExpectKind, val.Kind())
+		if have := AttributeType(tt.Attr); have != tt.Expect {
+			t.Errorf("Attr=%v, Expect=%v, Have=%v", tt.Attr, tt.Expect, have)
 		}
 	}
+
+	if !IsNull(nil) || !IsNull(&dynamodb.AttributeValue{NULL: aws.Bool(true)}) {
+		t.Error("Expect nil and NULL attributes to be null")
+	}
+	if IsNull(&dynamodb.AttributeValue{NULL: aws.Bool(false)}) || IsNull(&dynamodb.AttributeValue{S: aws.String("a")}) {
+		t.Error("Expect other attributes not to be null")
+	}
 }
 
 func TestConvertFromAttributesPrimitives(t *testing.T) {
@@ -233,6 +252,95 @@ func TestConvertFromAttributesNestedStructs(t *testing.T) {
 	verify(to, expect, t)
 }
 
+func TestConvertFromAttributesMaps(t *testing.T) {
+	t.Parallel()
+
+	from := map[string]*dynamodb.AttributeValue{
+		"TString": &dynamodb.AttributeValue{
+			S: aws.String("StringString"),
+		},
+		"TStruct": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"TInt": &dynamodb.AttributeValue{
+					N: aws.String("-1234"),
+				},
+				"TFloat32": &dynamodb.AttributeValue{
+					N: aws.String("3.14"),
+				},
+			},
+		},
+		"TMap": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+			},
+		},
+		"TNested": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{
+						"b": &dynamodb.AttributeValue{
+							S: aws.String("c"),
+						},
+					},
+				},
+			},
+		},
+		"TInterface": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					L: []*dynamodb.AttributeValue{
+						&dynamodb.AttributeValue{
+							M: map[string]*dynamodb.AttributeValue{
+								"b": &dynamodb.AttributeValue{
+									BOOL: aws.Bool(true),
+								},
+							},
+						},
+						&dynamodb.AttributeValue{
+							N: aws.String("2"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expect := &documentStruct{
+		TString: "StringString",
+		TStruct: &subNestedStruct{
+			TInt:     -1234,
+			TFloat32: 3.14,
+		},
+		TMap: map[string]int{
+			"a": 1,
+		},
+		TNested: map[string]map[string]string{
+			"a": map[string]string{
+				"b": "c",
+			},
+		},
+		TInterface: map[string]interface{}{
+			"a": []interface{}{
+				map[string]interface{}{
+					"b": true,
+				},
+				float64(2),
+			},
+		},
+	}
+
+	to := new(documentStruct)
+	if err := ConvertFromAttributes(from, to); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, to) {
+		t.Errorf("Expect=%v, Have=%v", expect, to)
+	}
+}
+
 func TestConvertFromAttributesOverflow(t *testing.T) {
 	t.Parallel()
 
@@ -368,7 +476,14 @@ func TestConvertToAttributesNestedStructs(t *testing.T) {
 			BOOL: aws.Bool(false),
 		},
 		"TStruct": &dynamodb.AttributeValue{
-			S: aws.String(`{"TInt":-1234,"TFloat32":3.14}`),
+			M: map[string]*dynamodb.AttributeValue{
+				"TInt": &dynamodb.AttributeValue{
+					N: aws.String("-1234"),
+				},
+				"TFloat32": &dynamodb.AttributeValue{
+					N: aws.String("3.14"),
+				},
+			},
 		},
 	}
 
@@ -391,122 +506,2642 @@ func TestConvertToAttributesNestedStructs(t *testing.T) {
 	}
 }
 
-func TestFieldByName(t *testing.T) {
+func TestConvertToAttributesMaps(t *testing.T) {
 	t.Parallel()
 
-	have := &taggedStruct{
-		Tag1: "tag1",
-		Tag2: "tag2",
+	from := &mapStruct{
+		TMap: map[string]int{
+			"a": 1,
+		},
+		TNested: map[string]map[string]string{
+			"a": map[string]string{
+				"b": "c",
+				"d": "",
+			},
+		},
+		TJSON: map[string]int{
+			"a": 1,
+		},
+		TStructJSON: subNestedStruct{
+			TInt: 1,
+		},
 	}
-	tests := []struct {
-		Tag    string
-		Expect string
-	}{
-		{
-			Tag:    "Tag1",
-			Expect: "tag1",
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"TMap": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+			},
 		},
-		{
-			Tag:    "untag1",
-			Expect: "tag1",
+		"TNested": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{
+						"b": &dynamodb.AttributeValue{
+							S: aws.String("c"),
+						},
+					},
+				},
+			},
+		},
+		"TJSON": &dynamodb.AttributeValue{
+			S: aws.String(`{"a":1}`),
+		},
+		"TStructJSON": &dynamodb.AttributeValue{
+			S: aws.String(`{"TInt":1,"TFloat32":0}`),
 		},
 	}
 
-	for _, tt := range tests {
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		v := fieldByName(reflect.ValueOf(have), tt.Tag)
-		if v.String() != tt.Expect {
-			t.Errorf("Tag=%s, Expect=%s, Have=%s", tt.Tag, tt.Expect, v.String())
-		}
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	if _, err := ConvertToAttributes(&struct{ TMap map[int]int }{map[int]int{1: 1}}); !errors.Is(err, ErrConversionNotSupported) {
+		t.Errorf("Expect=%v, Have=%v", ErrConversionNotSupported, err)
 	}
 }
 
-func verify(to, expect interface{}, t *testing.T) {
+func TestConvertListsRoundTrip(t *testing.T) {
+	t.Parallel()
 
-	ev := reflect.ValueOf(expect)
-	if ev.Kind() == reflect.Ptr || ev.Kind() == reflect.Interface {
-		ev = ev.Elem()
+	from := &listStruct{
+		TStructs: []subNestedStruct{
+			{TInt: 1, TFloat32: 1.5},
+			{TInt: 2},
+		},
+		TPtrs: []*subNestedStruct{
+			{TInt: 3},
+			nil,
+		},
+		TMaps: []map[string]string{
+			{"a": "b"},
+		},
+		TMixed: []interface{}{
+			"a",
+			float64(1),
+			true,
+			map[string]interface{}{"b": "c"},
+			[]interface{}{"d"},
+		},
+		TArray: [2]subNestedStruct{
+			{TInt: 4},
+			{TInt: 5},
+		},
+		TNested: [][]string{
+			{"a", "b"},
+			{"c"},
+		},
+		TInts: []int{1, 2},
 	}
-	et := ev.Type()
 
-	tv := reflect.ValueOf(to)
-	if tv.Kind() == reflect.Ptr || tv.Kind() == reflect.Interface {
-		tv = tv.Elem()
+	expect := map[string]*dynamodb.AttributeValue{
+		"TPtrs": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{
+						"TInt": &dynamodb.AttributeValue{
+							N: aws.String("3"),
+						},
+						"TFloat32": &dynamodb.AttributeValue{
+							N: aws.String("0"),
+						},
+					},
+				},
+				&dynamodb.AttributeValue{
+					NULL: aws.Bool(true),
+				},
+			},
+		},
+		"TMixed": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					S: aws.String("a"),
+				},
+				&dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+				&dynamodb.AttributeValue{
+					BOOL: aws.Bool(true),
+				},
+				&dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{
+						"b": &dynamodb.AttributeValue{
+							S: aws.String("c"),
+						},
+					},
+				},
+				&dynamodb.AttributeValue{
+					L: []*dynamodb.AttributeValue{
+						&dynamodb.AttributeValue{
+							S: aws.String("d"),
+						},
+					},
+				},
+			},
+		},
+		"TNested": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					SS: []*string{aws.String("a"), aws.String("b")},
+				},
+				&dynamodb.AttributeValue{
+					SS: []*string{aws.String("c")},
+				},
+			},
+		},
 	}
 
-	for i := 0; i < ev.NumField(); i++ {
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		ef := ev.Field(i)
-		tf := tv.FieldByName(et.Field(i).Name)
-		ek := ef.Kind()
-		if ek == reflect.Ptr {
-			ek = ef.Elem().Kind()
+	for k, v := range expect {
+
+		if !reflect.DeepEqual(v, have[k]) {
+			t.Errorf("Field=%s, Expect=%v, Have=%v", k, v, have[k])
 		}
+	}
 
-		switch ek {
+	to := new(listStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
 
-		case reflect.Struct:
-			verify(tf.Interface(), ef.Interface(), t)
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+}
 
-		case reflect.Slice:
+func TestConvertDynamoDBTags(t *testing.T) {
+	t.Parallel()
 
-			if ef.Len() != tf.Len() {
+	from := &dynamoTaggedStruct{
+		Name:     "a",
+		JSONOnly: "b",
+		Skip:     "c",
+		Count:    10,
+		Tags:     []string{"b", "a", "b"},
+		IDs:      []int{1, 2},
+		Raw:      "raw",
+		Blob:     []byte("blob"),
+		Keys:     []string{"k"},
+	}
 
-				t.Errorf(
-					"%s: Len Expect=%d, Received=%d",
-					et.Field(i).Name,
-					ef.Len(),
-					tf.Len(),
-				)
-				continue
-			}
-			for i := 0; i < ef.Len(); i++ {
+	expect := map[string]*dynamodb.AttributeValue{
+		"name": &dynamodb.AttributeValue{
+			S: aws.String("a"),
+		},
+		"json_only": &dynamodb.AttributeValue{
+			S: aws.String("b"),
+		},
+		"count": &dynamodb.AttributeValue{
+			S: aws.String("10"),
+		},
+		"tags": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					S: aws.String("b"),
+				},
+				&dynamodb.AttributeValue{
+					S: aws.String("a"),
+				},
+				&dynamodb.AttributeValue{
+					S: aws.String("b"),
+				},
+			},
+		},
+		"ids": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1"), aws.String("2")},
+		},
+		"raw": &dynamodb.AttributeValue{
+			B: []byte("raw"),
+		},
+		"blob": &dynamodb.AttributeValue{
+			B: []byte("blob"),
+		},
+		"keys": &dynamodb.AttributeValue{
+			BS: [][]byte{[]byte("k")},
+		},
+	}
 
-				efi := ef.Index(i)
-				tfi := tf.Index(i)
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-				if efi.Kind() == reflect.Slice {
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
 
-					if !reflect.DeepEqual(efi.Interface(), tfi.Interface()) {
-						t.Errorf(
-							"%s: Expect=%v, Received=%v",
-							et.Field(i).Name,
-							efi.Interface(),
-							tfi.Interface(),
-						)
-						continue
-					}
+	to := new(dynamoTaggedStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
 
-				} else {
+	from.Skip = ""
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
 
-					if efi.Interface() != tfi.Interface() {
+	if _, err := ConvertToAttributes(&struct {
+		TMaps []map[string]string `dynamodb:",set"`
+	}{[]map[string]string{{"a": "b"}}}); !errors.Is(err, ErrConversionNotSupported) {
+		t.Errorf("Expect=%v, Have=%v", ErrConversionNotSupported, err)
+	}
+}
 
-						t.Errorf(
-							"%s: Expect=%v, Received=%v",
-							et.Field(i).Name,
-							efi.Interface(),
-							tfi.Interface(),
-						)
-					}
-				}
-			}
+func TestConvertJSONTags(t *testing.T) {
+	t.Parallel()
 
-		default:
-			if ef.Interface() != tf.Interface() {
+	from := &jsonTaggedStruct{
+		Zip:      18104,
+		Hidden:   "hidden",
+		Dash:     "dash",
+		Count:    -5,
+		Price:    1.25,
+		Flag:     true,
+		Override: "override",
+	}
 
-				t.Errorf(
-					"%s: Expect=%v, Received=%v",
-					et.Field(i).Name,
-					ef.Interface(),
-					tf.Interface(),
-				)
-			}
-		}
+	expect := map[string]*dynamodb.AttributeValue{
+		"zip": &dynamodb.AttributeValue{
+			N: aws.String("18104"),
+		},
+		"-": &dynamodb.AttributeValue{
+			S: aws.String("dash"),
+		},
+		"Count": &dynamodb.AttributeValue{
+			S: aws.String("-5"),
+		},
+		"price": &dynamodb.AttributeValue{
+			S: aws.String("1.25"),
+		},
+		"flag": &dynamodb.AttributeValue{
+			S: aws.String("true"),
+		},
+		"override": &dynamodb.AttributeValue{
+			S: aws.String("override"),
+		},
 	}
-}
 
-type primitivesStruct struct {
-	TString      string
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(jsonTaggedStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	from.Hidden = ""
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+}
+
+func TestConvertMarshalers(t *testing.T) {
+	t.Parallel()
+
+	from := &marshalerStruct{
+		Price:  money(150),
+		Prices: []money{1, 2},
+		ByName: map[string]money{
+			"a": 3,
+		},
+		Location:  point{1, 2},
+		Locations: []point{{3, 4}},
+		Nested: subMarshalerStruct{
+			Location: &point{5, 6},
+		},
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Price": &dynamodb.AttributeValue{
+			S: aws.String("$1.50"),
+		},
+		"Prices": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					S: aws.String("$0.01"),
+				},
+				&dynamodb.AttributeValue{
+					S: aws.String("$0.02"),
+				},
+			},
+		},
+		"ByName": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					S: aws.String("$0.03"),
+				},
+			},
+		},
+		"Location": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1"), aws.String("2")},
+		},
+		"Locations": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					NS: []*string{aws.String("3"), aws.String("4")},
+				},
+			},
+		},
+		"Nested": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"Location": &dynamodb.AttributeValue{
+					NS: []*string{aws.String("5"), aws.String("6")},
+				},
+			},
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(marshalerStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	item, err := ConvertToAttributes(&marshalerItem{ID: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := item["pk"]; !ok || *v.S != "ITEM#abc" {
+		t.Errorf("Expect=ITEM#abc, Have=%v", item)
+	}
+
+	back := new(marshalerItem)
+	if err := ConvertFromAttributes(item, back); err != nil {
+		t.Fatal(err)
+	}
+	if back.ID != "abc" {
+		t.Errorf("Expect=abc, Have=%s", back.ID)
+	}
+}
+
+func TestConvertTextAndBinaryMarshalers(t *testing.T) {
+	t.Parallel()
+
+	from := &textMarshalerStruct{
+		IP:     net.ParseIP("10.0.0.1"),
+		IPs:    []net.IP{net.ParseIP("10.0.0.2")},
+		Level:  levelHigh,
+		Digest: digest{1, 2},
+		Grid: map[coord]string{
+			{1, 2}: "a",
+		},
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"IP": &dynamodb.AttributeValue{
+			S: aws.String("10.0.0.1"),
+		},
+		"IPs": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					S: aws.String("10.0.0.2"),
+				},
+			},
+		},
+		"Level": &dynamodb.AttributeValue{
+			S: aws.String("high"),
+		},
+		"Digest": &dynamodb.AttributeValue{
+			B: []byte{1, 2},
+		},
+		"Grid": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"1,2": &dynamodb.AttributeValue{
+					S: aws.String("a"),
+				},
+			},
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(textMarshalerStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	if !from.IP.Equal(to.IP) || len(to.IPs) != 1 || !from.IPs[0].Equal(to.IPs[0]) {
+		t.Errorf("IP: Expect=%v %v, Have=%v %v", from.IP, from.IPs, to.IP, to.IPs)
+	}
+	if to.Level != from.Level || to.Digest != from.Digest || !reflect.DeepEqual(to.Grid, from.Grid) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	// earlier versions stored TextMarshalers as JSON strings
+	var (
+		l level
+		c coord
+	)
+	if err := Unmarshal(&dynamodb.AttributeValue{S: aws.String(`"high"`)}, &l); err != nil || l != levelHigh {
+		t.Errorf("Expect=%v, Have=%v %v", levelHigh, l, err)
+	}
+	if err := Unmarshal(&dynamodb.AttributeValue{S: aws.String(`"1,2"`)}, &c); err != nil || c != (coord{1, 2}) {
+		t.Errorf("Expect=%v, Have=%v %v", coord{1, 2}, c, err)
+	}
+
+	// text that is itself quoted must survive a round trip
+	quoted := label(`"quoted"`)
+	av, err := Marshal(quoted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lb label
+	if err := Unmarshal(av, &lb); err != nil || lb != quoted {
+		t.Errorf("Expect=%v, Have=%v %v", quoted, lb, err)
+	}
+}
+
+func TestConvertTimes(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2016, time.March, 1, 12, 30, 15, 123456789, time.UTC)
+
+	from := &timeStruct{
+		Created:  at,
+		Expires:  at,
+		Seen:     at,
+		Updated:  at,
+		Times:    []time.Time{at},
+		Timeout:  90 * time.Second,
+		Interval: 90 * time.Minute,
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Created": &dynamodb.AttributeValue{
+			S: aws.String("2016-03-01T12:30:15.123456789Z"),
+		},
+		"Expires": &dynamodb.AttributeValue{
+			N: aws.String("1456835415"),
+		},
+		"Seen": &dynamodb.AttributeValue{
+			N: aws.String("1456835415123"),
+		},
+		"Updated": &dynamodb.AttributeValue{
+			N: aws.String("1456835415123456789"),
+		},
+		"Times": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					N: aws.String("1456835415"),
+				},
+			},
+		},
+		"Timeout": &dynamodb.AttributeValue{
+			N: aws.String("90000000000"),
+		},
+		"Interval": &dynamodb.AttributeValue{
+			S: aws.String("1h30m0s"),
+		},
+		"Zero": &dynamodb.AttributeValue{
+			S: aws.String("0001-01-01T00:00:00Z"),
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(timeStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name   string
+		Have   time.Time
+		Expect time.Time
+	}{
+		{"Created", to.Created, at},
+		{"Expires", to.Expires, at.Truncate(time.Second)},
+		{"Seen", to.Seen, at.Truncate(time.Millisecond)},
+		{"Updated", to.Updated, at},
+		{"Times", to.Times[0], at.Truncate(time.Second)},
+	}
+
+	for _, tt := range tests {
+
+		if !tt.Have.Equal(tt.Expect) {
+			t.Errorf("%s: Expect=%v, Have=%v", tt.Name, tt.Expect, tt.Have)
+		}
+	}
+
+	if to.Timeout != from.Timeout || to.Interval != from.Interval {
+		t.Errorf("Duration: Expect=%v %v, Have=%v %v", from.Timeout, from.Interval, to.Timeout, to.Interval)
+	}
+
+	// any of the forms are accepted when decoding
+	other := map[string]*dynamodb.AttributeValue{
+		"Created": &dynamodb.AttributeValue{
+			N: aws.String("1456835415"),
+		},
+		"Expires": &dynamodb.AttributeValue{
+			S: aws.String("2016-03-01T12:30:15Z"),
+		},
+		"Timeout": &dynamodb.AttributeValue{
+			S: aws.String("1m30s"),
+		},
+		"Interval": &dynamodb.AttributeValue{
+			N: aws.String("5400000000000"),
+		},
+	}
+
+	to = new(timeStruct)
+	if err := ConvertFromAttributes(other, to); err != nil {
+		t.Fatal(err)
+	}
+
+	if !to.Created.Equal(at.Truncate(time.Second)) || !to.Expires.Equal(at.Truncate(time.Second)) {
+		t.Errorf("Expect=%v, Have=%v %v", at.Truncate(time.Second), to.Created, to.Expires)
+	}
+	if to.Timeout != from.Timeout || to.Interval != from.Interval {
+		t.Errorf("Duration: Expect=%v %v, Have=%v %v", from.Timeout, from.Interval, to.Timeout, to.Interval)
+	}
+
+	// items written by earlier versions hold times as JSON strings
+	legacy := map[string]*dynamodb.AttributeValue{
+		"Created": &dynamodb.AttributeValue{
+			S: aws.String(`"2016-03-01T00:00:00Z"`),
+		},
+	}
+
+	to = new(timeStruct)
+	if err := ConvertFromAttributes(legacy, to); err != nil {
+		t.Fatal(err)
+	}
+	if expect := time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC); !to.Created.Equal(expect) {
+		t.Errorf("Expect=%v, Have=%v", expect, to.Created)
+	}
+
+	// milliseconds are kept for any time, while nanoseconds only fit an
+	// int64 between the years 1678 and 2262
+	future := time.Date(3000, time.January, 1, 0, 0, 0, 5000000, time.UTC)
+	for _, seen := range []time.Time{time.Time{}, future} {
+
+		have, err := ConvertToAttributes(&timeStruct{Seen: seen, Updated: at})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		to := new(timeStruct)
+		if err := ConvertFromAttributes(have, to); err != nil {
+			t.Fatal(err)
+		}
+		if !to.Seen.Equal(seen) {
+			t.Errorf("Seen: Expect=%v, Have=%v", seen, to.Seen)
+		}
+
+		_, err = ConvertToAttributes(&timeStruct{Updated: seen})
+		if !errors.Is(err, ErrNumberRange) {
+			t.Errorf("Updated %v: Expect=%v, Have=%v", seen, ErrNumberRange, err)
+		}
+	}
+}
+
+func TestConvertEmbeddedStructs(t *testing.T) {
+	t.Parallel()
+
+	from := &embeddedStruct{
+		keyFields: keyFields{
+			PK: "pk",
+			SK: "sk",
+		},
+		Audit: &Audit{
+			CreatedBy: "jane",
+			Name:      "hidden",
+			Version:   1,
+		},
+		versionFields: versionFields{
+			Version: 2,
+		},
+		Meta: Meta{
+			Source: "api",
+		},
+		Name: "name",
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"pk": &dynamodb.AttributeValue{
+			S: aws.String("pk"),
+		},
+		"sk": &dynamodb.AttributeValue{
+			S: aws.String("sk"),
+		},
+		"CreatedBy": &dynamodb.AttributeValue{
+			S: aws.String("jane"),
+		},
+		"meta": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"Source": &dynamodb.AttributeValue{
+					S: aws.String("api"),
+				},
+			},
+		},
+		"Name": &dynamodb.AttributeValue{
+			S: aws.String("name"),
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(embeddedStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	from.Audit.Name = ""
+	from.Audit.Version = 0
+	from.versionFields.Version = 0
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	// a nil embedded pointer contributes no attributes
+	have, err = ConvertToAttributes(&embeddedStruct{Name: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := have["CreatedBy"]; ok {
+		t.Errorf("Unexpected CreatedBy=%v", have["CreatedBy"])
+	}
+
+	// a nil pointer to an unexported struct can't be allocated
+	hidden := map[string]*dynamodb.AttributeValue{
+		"Label": &dynamodb.AttributeValue{S: aws.String("label")},
+	}
+	for _, d := range []*Decoder{NewDecoder(), NewDecoder(DisallowUnknownFields())} {
+
+		err := d.ConvertFromAttributes(hidden, new(unexportedEmbeddedStruct))
+		if !errors.Is(err, ErrUnexportedEmbeddedPointer) {
+			t.Errorf("Expect=%v, Have=%v", ErrUnexportedEmbeddedPointer, err)
+		}
+	}
+}
+
+func TestMarshalValues(t *testing.T) {
+	t.Parallel()
+
+	var nilPtr *int
+	tests := []struct {
+		From   interface{}
+		Expect *dynamodb.AttributeValue
+	}{
+		{
+			From:   "a",
+			Expect: &dynamodb.AttributeValue{S: aws.String("a")},
+		},
+		{
+			From:   -10,
+			Expect: &dynamodb.AttributeValue{N: aws.String("-10")},
+		},
+		{
+			From:   1.5,
+			Expect: &dynamodb.AttributeValue{N: aws.String("1.5")},
+		},
+		{
+			From:   true,
+			Expect: &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+		},
+		{
+			From:   aws.String("b"),
+			Expect: &dynamodb.AttributeValue{S: aws.String("b")},
+		},
+		{
+			From:   nil,
+			Expect: &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+		},
+		{
+			From:   nilPtr,
+			Expect: &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+		},
+		{
+			From:   []string{"a"},
+			Expect: &dynamodb.AttributeValue{SS: []*string{aws.String("a")}},
+		},
+		{
+			From: []interface{}{"a", 1},
+			Expect: &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{S: aws.String("a")},
+				&dynamodb.AttributeValue{N: aws.String("1")},
+			}},
+		},
+		{
+			From: map[string]int{"a": 1},
+			Expect: &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{N: aws.String("1")},
+			}},
+		},
+		{
+			From: &subNestedStruct{TInt: 1},
+			Expect: &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+				"TInt":     &dynamodb.AttributeValue{N: aws.String("1")},
+				"TFloat32": &dynamodb.AttributeValue{N: aws.String("0")},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+
+		have, err := Marshal(tt.From)
+		if err != nil {
+			t.Errorf("From=%v: %v", tt.From, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.Expect, have) {
+			t.Errorf("From=%v: Expect=%v, Have=%v", tt.From, tt.Expect, have)
+		}
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	t.Parallel()
+
+	var (
+		s  string
+		i  int
+		f  float64
+		b  bool
+		ss []string
+		l  []interface{}
+		m  map[string]int
+		st subNestedStruct
+	)
+
+	tests := []struct {
+		From   *dynamodb.AttributeValue
+		To     interface{}
+		Expect interface{}
+	}{
+		{
+			From:   &dynamodb.AttributeValue{S: aws.String("a")},
+			To:     &s,
+			Expect: "a",
+		},
+		{
+			From:   &dynamodb.AttributeValue{N: aws.String("-10")},
+			To:     &i,
+			Expect: -10,
+		},
+		{
+			From:   &dynamodb.AttributeValue{N: aws.String("1.5")},
+			To:     &f,
+			Expect: 1.5,
+		},
+		{
+			From:   &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+			To:     &b,
+			Expect: true,
+		},
+		{
+			From:   &dynamodb.AttributeValue{SS: []*string{aws.String("a")}},
+			To:     &ss,
+			Expect: []string{"a"},
+		},
+		{
+			From: &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{S: aws.String("a")},
+				&dynamodb.AttributeValue{N: aws.String("1")},
+			}},
+			To:     &l,
+			Expect: []interface{}{"a", float64(1)},
+		},
+		{
+			From: &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{N: aws.String("1")},
+			}},
+			To:     &m,
+			Expect: map[string]int{"a": 1},
+		},
+		{
+			From: &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+				"TInt": &dynamodb.AttributeValue{N: aws.String("1")},
+			}},
+			To:     &st,
+			Expect: subNestedStruct{TInt: 1},
+		},
+	}
+
+	for _, tt := range tests {
+
+		if err := Unmarshal(tt.From, tt.To); err != nil {
+			t.Errorf("From=%v: %v", tt.From, err)
+			continue
+		}
+		have := reflect.ValueOf(tt.To).Elem().Interface()
+		if !reflect.DeepEqual(tt.Expect, have) {
+			t.Errorf("From=%v: Expect=%v, Have=%v", tt.From, tt.Expect, have)
+		}
+	}
+
+	if err := Unmarshal(&dynamodb.AttributeValue{S: aws.String("a")}, s); !errors.Is(err, ErrNilTarget) {
+		t.Errorf("Expect=%v, Have=%v", ErrNilTarget, err)
+	}
+}
+
+func TestConvertFromAttributesInterfaces(t *testing.T) {
+	t.Parallel()
+
+	from := map[string]*dynamodb.AttributeValue{
+		"S": &dynamodb.AttributeValue{
+			S: aws.String("a"),
+		},
+		"N": &dynamodb.AttributeValue{
+			N: aws.String("12345678901234567890.5"),
+		},
+		"BOOL": &dynamodb.AttributeValue{
+			BOOL: aws.Bool(true),
+		},
+		"NULL": &dynamodb.AttributeValue{
+			NULL: aws.Bool(true),
+		},
+		"B": &dynamodb.AttributeValue{
+			B: []byte("b"),
+		},
+		"SS": &dynamodb.AttributeValue{
+			SS: []*string{aws.String("a")},
+		},
+		"NS": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1.5")},
+		},
+		"BS": &dynamodb.AttributeValue{
+			BS: [][]byte{[]byte("b")},
+		},
+		"L": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+			},
+		},
+		"M": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					S: aws.String("b"),
+				},
+			},
+		},
+	}
+
+	expect := map[string]interface{}{
+		"S":    "a",
+		"N":    12345678901234567890.5,
+		"BOOL": true,
+		"NULL": nil,
+		"B":    []byte("b"),
+		"SS":   []string{"a"},
+		"NS":   []float64{1.5},
+		"BS":   [][]byte{[]byte("b")},
+		"L":    []interface{}{float64(1)},
+		"M":    map[string]interface{}{"a": "b"},
+	}
+
+	to := make(map[string]interface{})
+	if err := ConvertFromAttributes(from, &to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, to) {
+		t.Errorf("Expect=%v, Have=%v", expect, to)
+	}
+
+	var i interface{}
+	if err := ConvertFromAttributes(from, &i); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, i) {
+		t.Errorf("Expect=%v, Have=%v", expect, i)
+	}
+
+	// numbers keep their precision with UseNumber
+	expect["N"] = Number("12345678901234567890.5")
+	expect["NS"] = []Number{"1.5"}
+	expect["L"] = []interface{}{Number("1")}
+
+	to = nil
+	if err := NewDecoder(UseNumber()).ConvertFromAttributes(from, &to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, to) {
+		t.Errorf("Expect=%v, Have=%v", expect, to)
+	}
+}
+
+func TestConvertNumber(t *testing.T) {
+	t.Parallel()
+
+	from := &numberStruct{
+		Price:  "12345678901234567890.123456789",
+		Prices: []Number{"1", "2.5"},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := have["Price"]; v == nil || v.N == nil || *v.N != string(from.Price) {
+		t.Errorf("Expect=%s, Have=%v", from.Price, v)
+	}
+
+	to := new(numberStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	if n, err := to.Prices[1].Float64(); err != nil || n != 2.5 {
+		t.Errorf("Expect=2.5, Have=%v %v", n, err)
+	}
+
+	if _, err := ConvertToAttributes(&numberStruct{Price: "abc"}); !errors.Is(err, ErrInvalidStringForNumber) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidStringForNumber, err)
+	}
+}
+
+func TestConvertBig(t *testing.T) {
+	t.Parallel()
+
+	i, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	f, _, _ := big.ParseFloat("1234567890.12345678901234567890", 10, 128, big.ToNearestEven)
+
+	from := &bigStruct{
+		Int:   i,
+		Float: f,
+		Rat:   big.NewRat(1, 8),
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]string{
+		"Int":   "123456789012345678901234567890",
+		"Float": "1.2345678901234567890123456789e+09",
+		"Rat":   "0.125",
+	}
+	for name, n := range expect {
+		if v := have[name]; v == nil || v.N == nil || *v.N != n {
+			t.Errorf("%s: Expect=%s, Have=%v", name, n, v)
+		}
+	}
+
+	to := new(bigStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+	if to.Int.Cmp(from.Int) != 0 || to.Float.Cmp(from.Float) != 0 || to.Rat.Cmp(from.Rat) != 0 {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	if n, err := Number(expect["Int"]).BigInt(); err != nil || n.Cmp(i) != 0 {
+		t.Errorf("Expect=%v, Have=%v %v", i, n, err)
+	}
+	if _, err := Number("1.5").BigInt(); !errors.Is(err, ErrInvalidStringForNumber) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidStringForNumber, err)
+	}
+}
+
+func TestNumberRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		From   interface{}
+		Expect error
+	}{
+		{From: &numberStruct{Price: "12345678901234567890123456789012345678"}},
+		{From: &numberStruct{Price: "1.2345678901234567890123456789012345678e125"}},
+		{From: &numberStruct{Price: "1e-130"}},
+		{From: &numberStruct{Price: "-0.000"}},
+		{From: &numberStruct{Price: "1000000000000000000000000000000000000000"}},
+		{From: &numberStruct{Price: "123456789012345678901234567890123456789"}, Expect: ErrNumberRange},
+		{From: &numberStruct{Price: "1e126"}, Expect: ErrNumberRange},
+		{From: &numberStruct{Price: "1e-131"}, Expect: ErrNumberRange},
+		{From: &numberStruct{Prices: []Number{"1e999999999999"}}, Expect: ErrNumberRange},
+		{From: &struct{ F float64 }{1e300}, Expect: ErrNumberRange},
+		{From: &struct{ F []float64 }{[]float64{1e-300}}, Expect: ErrNumberRange},
+		{From: &bigStruct{Rat: big.NewRat(1, 3)}, Expect: ErrNumberRange},
+	}
+
+	for _, test := range tests {
+
+		if _, err := ConvertToAttributes(test.From); !errors.Is(err, test.Expect) {
+			t.Errorf("%v: Expect=%v, Have=%v", test.From, test.Expect, err)
+		}
+	}
+}
+
+func TestConvertPointers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		one   = 1
+		zero  = 0
+		str   = "a"
+		ptr   = &str
+		at    = time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC)
+		other = 2
+	)
+
+	from := &pointerStruct{
+		Int:     &one,
+		ZeroInt: &zero,
+		PtrPtr:  &ptr,
+		Ints:    []*int{&one, nil},
+		ByName: map[string]*string{
+			"a": &str,
+		},
+		Time: &at,
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Int": &dynamodb.AttributeValue{
+			N: aws.String("1"),
+		},
+		"ZeroInt": &dynamodb.AttributeValue{
+			N: aws.String("0"),
+		},
+		"PtrPtr": &dynamodb.AttributeValue{
+			S: aws.String("a"),
+		},
+		"Nullable": &dynamodb.AttributeValue{
+			NULL: aws.Bool(true),
+		},
+		"Ints": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+				&dynamodb.AttributeValue{
+					NULL: aws.Bool(true),
+				},
+			},
+		},
+		"ByName": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					S: aws.String("a"),
+				},
+			},
+		},
+		"Time": &dynamodb.AttributeValue{
+			N: aws.String("1456790400"),
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	// a NULL attribute resets an already set pointer
+	to := &pointerStruct{
+		Nullable: &other,
+	}
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	if to.Int == nil || *to.Int != 1 || to.ZeroInt == nil || *to.ZeroInt != 0 {
+		t.Errorf("Int: Expect=1 0, Have=%v %v", to.Int, to.ZeroInt)
+	}
+	if to.PtrPtr == nil || *to.PtrPtr == nil || **to.PtrPtr != "a" {
+		t.Errorf("PtrPtr: Expect=a, Have=%v", to.PtrPtr)
+	}
+	if to.Nil != nil || to.Nullable != nil {
+		t.Errorf("Nil: Expect=nil nil, Have=%v %v", to.Nil, to.Nullable)
+	}
+	if len(to.Ints) != 2 || to.Ints[0] == nil || *to.Ints[0] != 1 || to.Ints[1] != nil {
+		t.Errorf("Ints: Expect=[1 nil], Have=%v", to.Ints)
+	}
+	if v, ok := to.ByName["a"]; !ok || v == nil || *v != "a" {
+		t.Errorf("ByName: Expect=a, Have=%v", to.ByName)
+	}
+	if to.Time == nil || !to.Time.Equal(at) {
+		t.Errorf("Time: Expect=%v, Have=%v", at, to.Time)
+	}
+}
+
+func TestConvertNulls(t *testing.T) {
+	t.Parallel()
+
+	from := nullableStruct{
+		Count: 3,
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Note": &dynamodb.AttributeValue{
+			NULL: aws.Bool(true),
+		},
+		"Count": &dynamodb.AttributeValue{
+			N: aws.String("3"),
+		},
+		"Big": &dynamodb.AttributeValue{
+			N: aws.String("0"),
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	// a NULL attribute holds no value, leaving the zero value of any field
+	to := nullableStruct{
+		Note:     "a",
+		Flag:     true,
+		Duration: time.Second,
+		Big:      *big.NewInt(1),
+	}
+	have["Count"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	have["Flag"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	have["Duration"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	have["Big"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	if err := NewDecoder(StrictTypes()).ConvertFromAttributes(have, &to); err != nil {
+		t.Fatal(err)
+	}
+	if to.Note != "" || to.Count != 0 || to.Flag || to.Duration != 0 || to.Big.Sign() != 0 {
+		t.Errorf("Expect=zero values, Have=%+v", to)
+	}
+
+	i := 5
+	if err := Unmarshal(&dynamodb.AttributeValue{NULL: aws.Bool(true)}, &i); err != nil {
+		t.Fatal(err)
+	}
+	if i != 0 {
+		t.Errorf("Expect=0, Have=%d", i)
+	}
+}
+
+func TestConvertZeroValues(t *testing.T) {
+	t.Parallel()
+
+	from := &zeroStruct{
+		List:     []subNestedStruct{},
+		Map:      map[string]int{},
+		Set:      []string{},
+		OmitList: []subNestedStruct{},
+		OmitMap:  map[string]int{},
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Int": &dynamodb.AttributeValue{
+			N: aws.String("0"),
+		},
+		"Bool": &dynamodb.AttributeValue{
+			BOOL: aws.Bool(false),
+		},
+		"Float": &dynamodb.AttributeValue{
+			N: aws.String("0"),
+		},
+		"Struct": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"TInt": &dynamodb.AttributeValue{
+					N: aws.String("0"),
+				},
+				"TFloat32": &dynamodb.AttributeValue{
+					N: aws.String("0"),
+				},
+			},
+		},
+		"Time": &dynamodb.AttributeValue{
+			S: aws.String("0001-01-01T00:00:00Z"),
+		},
+		"List": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{},
+		},
+		"Map": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{},
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	// omitting every zero value omits the struct too, since each of its
+	// fields are zero
+	expect = map[string]*dynamodb.AttributeValue{}
+
+	have, err = NewEncoder(OmitEmpty()).ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	// as is an array of zeros, which would otherwise be a set of
+	// duplicate members
+	have, err = NewEncoder(OmitEmpty()).ConvertToAttributes(zeroArrayStruct{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	expect = map[string]*dynamodb.AttributeValue{
+		"Struct": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"TInt": &dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+			},
+		},
+		"Array": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1"), aws.String("2"), aws.String("3")},
+		},
+	}
+
+	have, err = NewEncoder(OmitEmpty()).ConvertToAttributes(zeroArrayStruct{
+		Struct: subNestedStruct{TInt: 1},
+		Array:  [3]int{1, 2, 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+}
+
+func TestConvertBinary(t *testing.T) {
+	t.Parallel()
+
+	from := &binaryStruct{
+		Bytes:  []byte("bytes"),
+		Fixed:  [4]byte{1, 2, 3, 4},
+		Named:  blob("blob"),
+		Empty:  []byte{},
+		Nested: [][]byte{[]byte("a"), []byte("b")},
+		Ints:   [][]int{{1}},
+		List:   []byte{1},
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Bytes": &dynamodb.AttributeValue{
+			B: []byte("bytes"),
+		},
+		"Fixed": &dynamodb.AttributeValue{
+			B: []byte{1, 2, 3, 4},
+		},
+		"Named": &dynamodb.AttributeValue{
+			B: []byte("blob"),
+		},
+		"Nested": &dynamodb.AttributeValue{
+			BS: [][]byte{[]byte("a"), []byte("b")},
+		},
+		"Ints": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					NS: []*string{aws.String("1")},
+				},
+			},
+		},
+		"List": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					N: aws.String("1"),
+				},
+			},
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(binaryStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	from.Empty = nil
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	// a fixed size array must be filled exactly
+	short := map[string]*dynamodb.AttributeValue{
+		"Fixed": &dynamodb.AttributeValue{
+			B: []byte{1, 2},
+		},
+	}
+	if err := ConvertFromAttributes(short, new(binaryStruct)); !errors.Is(err, ErrInvalidConversion) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidConversion, err)
+	}
+
+	// byte slices written as number sets can still be read
+	legacy := map[string]*dynamodb.AttributeValue{
+		"Bytes": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1"), aws.String("2")},
+		},
+	}
+	to = new(binaryStruct)
+	if err := ConvertFromAttributes(legacy, to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(to.Bytes, []byte{1, 2}) {
+		t.Errorf("Expect=%v, Have=%v", []byte{1, 2}, to.Bytes)
+	}
+}
+
+func TestConvertSets(t *testing.T) {
+	t.Parallel()
+
+	from := &setStruct{
+		Strings: NewStringSet("b", "a"),
+		Numbers: NewNumberSet("2", "1.5"),
+		Binary:  NewBinarySet([]byte("y"), []byte("x")),
+		Ints:    map[int]struct{}{3: struct{}{}, 1: struct{}{}},
+		Flags:   map[string]bool{"on": true, "off": false},
+		Empty:   StringSet{},
+		Tagged:  []string{"a", "b"},
+		List:    []string{"b", "a", "b"},
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"Strings": &dynamodb.AttributeValue{
+			SS: []*string{aws.String("a"), aws.String("b")},
+		},
+		"Numbers": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1.5"), aws.String("2")},
+		},
+		"Binary": &dynamodb.AttributeValue{
+			BS: [][]byte{[]byte("x"), []byte("y")},
+		},
+		"Ints": &dynamodb.AttributeValue{
+			NS: []*string{aws.String("1"), aws.String("3")},
+		},
+		"Flags": &dynamodb.AttributeValue{
+			SS: []*string{aws.String("on")},
+		},
+		"Tagged": &dynamodb.AttributeValue{
+			SS: []*string{aws.String("a"), aws.String("b")},
+		},
+		"List": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{S: aws.String("b")},
+				&dynamodb.AttributeValue{S: aws.String("a")},
+				&dynamodb.AttributeValue{S: aws.String("b")},
+			},
+		},
+	}
+
+	have, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	to := new(setStruct)
+	if err := ConvertFromAttributes(have, to); err != nil {
+		t.Fatal(err)
+	}
+
+	from.Empty = nil
+	delete(from.Flags, "off")
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	if !to.Binary.Contains([]byte("x")) || to.Strings.Contains("c") {
+		t.Errorf("Unexpected set members %v, %v", to.Binary, to.Strings)
+	}
+
+	to.Strings.Remove("a")
+	if to.Strings.Contains("a") {
+		t.Errorf("Expect a to be removed from %v", to.Strings)
+	}
+
+	// duplicates are only reported for slices tagged as sets
+	dup := &setStruct{
+		Tagged: []string{"a", "a"},
+	}
+	if _, err := ConvertToAttributes(dup); !errors.Is(err, ErrDuplicateSetMember) {
+		t.Errorf("Expect=%v, Have=%v", ErrDuplicateSetMember, err)
+	}
+
+	// numbers are the same member if they have the same value
+	for _, from := range []interface{}{
+		&struct{ Set NumberSet }{NewNumberSet("1", "1.0", "1e0")},
+		&struct {
+			Set []Number `dynamodb:",set"`
+		}{[]Number{"1", "1.0"}},
+		&struct {
+			Set []Number `dynamodb:",set"`
+		}{[]Number{"-0", "0.00"}},
+	} {
+		if _, err := ConvertToAttributes(from); !errors.Is(err, ErrDuplicateSetMember) {
+			t.Errorf("%v: Expect=%v, Have=%v", from, ErrDuplicateSetMember, err)
+		}
+	}
+	distinct := &struct {
+		Set []Number `dynamodb:",set"`
+	}{[]Number{"1", "-1", "10", "0.1", "1e2"}}
+	if _, err := ConvertToAttributes(distinct); err != nil {
+		t.Errorf("Expect no error, Have=%v", err)
+	}
+}
+
+func TestDecoderStrictOptions(t *testing.T) {
+	t.Parallel()
+
+	av := func(s string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{S: aws.String(s)}
+	}
+
+	tests := []struct {
+		Opts   []DecoderOption
+		Item   map[string]*dynamodb.AttributeValue
+		To     interface{}
+		Expect error
+	}{
+		{
+			Item: map[string]*dynamodb.AttributeValue{"ID": av("a"), "Unknown": av("b")},
+			To:   new(strictStruct),
+		},
+		{
+			Opts:   []DecoderOption{DisallowUnknownFields()},
+			Item:   map[string]*dynamodb.AttributeValue{"ID": av("a"), "Unknown": av("b")},
+			To:     new(strictStruct),
+			Expect: ErrUnknownField,
+		},
+		{
+			Opts: []DecoderOption{DisallowUnknownFields()},
+			Item: map[string]*dynamodb.AttributeValue{"id": av("a")},
+			To:   new(strictStruct),
+		},
+		{
+			Item: map[string]*dynamodb.AttributeValue{"Count": av("1")},
+			To:   new(strictStruct),
+		},
+		{
+			Opts:   []DecoderOption{EnforceRequired()},
+			Item:   map[string]*dynamodb.AttributeValue{"Count": av("1")},
+			To:     new(strictStruct),
+			Expect: ErrMissingRequiredField,
+		},
+		{
+			Opts:   []DecoderOption{EnforceRequired()},
+			To:     new(strictStruct),
+			Expect: ErrMissingRequiredField,
+		},
+		{
+			To: new(strictStruct),
+		},
+		{
+			Opts: []DecoderOption{EnforceRequired()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"ID": &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+			},
+			To:     new(strictStruct),
+			Expect: ErrMissingRequiredField,
+		},
+		{
+			Opts: []DecoderOption{EnforceRequired()},
+			Item: map[string]*dynamodb.AttributeValue{"ID": av("a")},
+			To:   new(strictStruct),
+		},
+		{
+			Item: map[string]*dynamodb.AttributeValue{
+				"Count": &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+			},
+			To: new(strictStruct),
+		},
+		{
+			Opts: []DecoderOption{StrictTypes()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"Count": &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+			},
+			To:     new(strictStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts: []DecoderOption{StrictTypes()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"ID": &dynamodb.AttributeValue{N: aws.String("1")},
+			},
+			To:     new(strictStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts:   []DecoderOption{StrictTypes()},
+			Item:   map[string]*dynamodb.AttributeValue{"Count": av("1")},
+			To:     new(strictStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts: []DecoderOption{StrictTypes()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"Count": &dynamodb.AttributeValue{N: aws.String("1")},
+				"Flag":  av("true"),
+				"Tags": &dynamodb.AttributeValue{
+					SS: []*string{aws.String("a")},
+				},
+			},
+			To: new(strictStruct),
+		},
+		{
+			Item: map[string]*dynamodb.AttributeValue{"Timeout": av("12")},
+			To:   new(strictTimeStruct),
+		},
+		{
+			Opts:   []DecoderOption{StrictTypes()},
+			Item:   map[string]*dynamodb.AttributeValue{"Timeout": av("12")},
+			To:     new(strictTimeStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts:   []DecoderOption{StrictTypes()},
+			Item:   map[string]*dynamodb.AttributeValue{"Amount": av("12")},
+			To:     new(strictTimeStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts: []DecoderOption{StrictTypes()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"At": &dynamodb.AttributeValue{N: aws.String("1456835415")},
+			},
+			To:     new(strictTimeStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts:   []DecoderOption{StrictTypes()},
+			Item:   map[string]*dynamodb.AttributeValue{"Expires": av("2016-03-01T12:30:15Z")},
+			To:     new(strictTimeStruct),
+			Expect: ErrInvalidConversion,
+		},
+		{
+			Opts: []DecoderOption{StrictTypes()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"Timeout":  &dynamodb.AttributeValue{N: aws.String("12")},
+				"Interval": av("1h30m"),
+				"Amount":   &dynamodb.AttributeValue{N: aws.String("12")},
+				"At":       av("2016-03-01T12:30:15Z"),
+				"Expires":  &dynamodb.AttributeValue{N: aws.String("1456835415")},
+			},
+			To: new(strictTimeStruct),
+		},
+		{
+			Opts: []DecoderOption{StrictTypes()},
+			Item: map[string]*dynamodb.AttributeValue{
+				"Blobs": &dynamodb.AttributeValue{BS: [][]byte{[]byte("a")}},
+			},
+			To: new(strictSetStruct),
+		},
+	}
+
+	for i, test := range tests {
+
+		d := NewDecoder(test.Opts...)
+		if err := d.ConvertFromAttributes(test.Item, test.To); !errors.Is(err, test.Expect) {
+			t.Errorf("%d: Expect=%v, Have=%v", i, test.Expect, err)
+		}
+	}
+
+	from := strictSetStruct{Blobs: NewBinarySet([]byte("a"))}
+	item, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var to strictSetStruct
+	if err := NewDecoder(StrictTypes()).ConvertFromAttributes(item, &to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+}
+
+func TestErrorPaths(t *testing.T) {
+	t.Parallel()
+
+	item := map[string]*dynamodb.AttributeValue{
+		"orders": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{
+				&dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{},
+				},
+				&dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{
+						"items": &dynamodb.AttributeValue{
+							M: map[string]*dynamodb.AttributeValue{
+								"sku": &dynamodb.AttributeValue{
+									S: aws.String("abc"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := ConvertFromAttributes(item, new(orderList))
+	if !errors.Is(err, ErrInvalidStringForNumber) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidStringForNumber, err)
+	}
+
+	var ute *UnmarshalTypeError
+	if !errors.As(err, &ute) {
+		t.Fatalf("Expect an UnmarshalTypeError, Have=%v", err)
+	}
+	expect := &UnmarshalTypeError{
+		Path:          "orders[1].items.sku",
+		AttributeType: TypeString,
+		GoType:        reflect.TypeOf(0),
+		Value:         "abc",
+		Err:           ErrInvalidStringForNumber,
+	}
+	if !reflect.DeepEqual(expect, ute) {
+		t.Errorf("Expect=%v, Have=%v", expect, ute)
+	}
+
+	from := &orderList{
+		Orders: []order{
+			{Items: orderItem{Price: math.NaN()}},
+		},
+	}
+
+	_, err = ConvertToAttributes(from)
+	if !errors.Is(err, ErrInvalidFloat) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidFloat, err)
+	}
+
+	var me *MarshalError
+	if !errors.As(err, &me) {
+		t.Fatalf("Expect a MarshalError, Have=%v", err)
+	}
+	if me.Path != "orders[0].items.price" || me.GoType != reflect.TypeOf(0.0) {
+		t.Errorf("Expect=orders[0].items.price float64, Have=%s %v", me.Path, me.GoType)
+	}
+
+	// documents decoded into interface{} carry the path of the attribute
+	doc := map[string]*dynamodb.AttributeValue{
+		"Doc": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"a": &dynamodb.AttributeValue{
+					L: []*dynamodb.AttributeValue{
+						&dynamodb.AttributeValue{N: aws.String("1")},
+						&dynamodb.AttributeValue{N: aws.String("abc")},
+					},
+				},
+			},
+		},
+	}
+	var docStruct struct {
+		Doc interface{}
+	}
+	err = ConvertFromAttributes(doc, &docStruct)
+	if !errors.As(err, &ute) {
+		t.Fatalf("Expect an UnmarshalTypeError, Have=%v", err)
+	}
+	if ute.Path != "Doc.a[1]" || ute.AttributeType != TypeNumber || ute.Value != "abc" {
+		t.Errorf("Expect=Doc.a[1] N abc, Have=%s %v %s", ute.Path, ute.AttributeType, ute.Value)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	t.Parallel()
+
+	sku := func(s string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{
+				"items": &dynamodb.AttributeValue{
+					M: map[string]*dynamodb.AttributeValue{
+						"sku": &dynamodb.AttributeValue{
+							S: aws.String(s),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"orders": &dynamodb.AttributeValue{
+			L: []*dynamodb.AttributeValue{sku("a"), sku("1"), sku("b")},
+		},
+	}
+
+	to := new(orderList)
+	err := NewDecoder(CollectDecodeErrors()).ConvertFromAttributes(item, to)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expect Errors, Have=%v", err)
+	}
+	if paths := errorPaths(errs); !reflect.DeepEqual(paths, []string{"orders[0].items.sku", "orders[2].items.sku"}) {
+		t.Errorf("Unexpected paths %v", paths)
+	}
+	if !errors.Is(err, ErrInvalidStringForNumber) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidStringForNumber, err)
+	}
+	if len(to.Orders) != 3 || to.Orders[1].Items.SKU != 1 {
+		t.Errorf("Expect the valid order to be decoded, Have=%v", to.Orders)
+	}
+
+	from := &struct {
+		ID     string
+		Orders []order `dynamodb:"orders"`
+	}{
+		ID: "id",
+		Orders: []order{
+			{Items: orderItem{Price: math.Inf(1)}},
+			{Items: orderItem{Price: 1}},
+			{Items: orderItem{Price: math.NaN()}},
+		},
+	}
+
+	have, err := NewEncoder(CollectEncodeErrors()).ConvertToAttributes(from)
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expect Errors, Have=%v", err)
+	}
+	if paths := errorPaths(errs); !reflect.DeepEqual(paths, []string{"orders[0].items.price", "orders[2].items.price"}) {
+		t.Errorf("Unexpected paths %v", paths)
+	}
+	if v := have["ID"]; v == nil || v.S == nil || *v.S != "id" {
+		t.Errorf("Expect the ID to be encoded, Have=%v", have)
+	}
+
+	// without the option, the first error stops conversion
+	if _, err := ConvertToAttributes(from); errors.As(err, &errs) {
+		t.Errorf("Expect a single error, Have=%v", err)
+	}
+
+
+	// the keys of items and maps are met in order on every run
+	bad := func(s string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{S: aws.String(s)}
+	}
+	byKey := map[string]*dynamodb.AttributeValue{
+		"c": bad("x"),
+		"a": bad("y"),
+		"b": bad("z"),
+		"d": &dynamodb.AttributeValue{
+			M: map[string]*dynamodb.AttributeValue{"z": bad("x"), "y": bad("y")},
+		},
+	}
+	floats := map[string]float64{"c": math.NaN(), "a": math.Inf(1), "b": math.Inf(-1)}
+	for i := 0; i < 10; i++ {
+
+		var to struct {
+			A, B, C int
+			D       map[string]int
+		}
+		err := NewDecoder(CollectDecodeErrors()).ConvertFromAttributes(byKey, &to)
+		if !errors.As(err, &errs) {
+			t.Fatalf("Expect Errors, Have=%v", err)
+		}
+		if paths := errorPaths(errs); !reflect.DeepEqual(paths, []string{"a", "b", "c", "d.y", "d.z"}) {
+			t.Errorf("Unexpected paths %v", paths)
+		}
+
+		_, err = NewEncoder(CollectEncodeErrors()).ConvertToAttributes(floats)
+		if !errors.As(err, &errs) {
+			t.Fatalf("Expect Errors, Have=%v", err)
+		}
+		if paths := errorPaths(errs); !reflect.DeepEqual(paths, []string{"a", "b", "c"}) {
+			t.Errorf("Unexpected paths %v", paths)
+		}
+	}
+}
+
+// errorPaths returns the sorted paths of each error in errs
+func errorPaths(errs Errors) []string {
+
+	var paths []string
+	for _, err := range errs {
+
+		switch e := err.(type) {
+
+		case *UnmarshalTypeError:
+			paths = append(paths, e.Path)
+
+		case *MarshalError:
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths
+}
+
+func TestEncoderOptions(t *testing.T) {
+	t.Parallel()
+
+	from := optionsStruct{
+		ID:     "a",
+		Count:  2,
+		Active: true,
+		Tags:   []string{"x"},
+		Amount: Number("1.5"),
+		Temp:   21.5,
+		Wait:   90 * time.Second,
+	}
+
+	upper := func(goName string) string {
+		return strings.ToUpper(goName)
+	}
+	temp := func(v interface{}) (*dynamodb.AttributeValue, error) {
+		return &dynamodb.AttributeValue{
+			S: aws.String(strconv.FormatFloat(float64(v.(celsius)), 'f', -1, 64) + "C"),
+		}, nil
+	}
+
+	tests := []struct {
+		Opts   []EncoderOption
+		Expect map[string]*dynamodb.AttributeValue
+	}{
+		{
+			Expect: map[string]*dynamodb.AttributeValue{
+				"ignored": &dynamodb.AttributeValue{S: aws.String("a")},
+				"Count":   &dynamodb.AttributeValue{N: aws.String("2")},
+				"Active":  &dynamodb.AttributeValue{BOOL: aws.Bool(true)},
+				"Tags":    &dynamodb.AttributeValue{SS: []*string{aws.String("x")}},
+				"Amount":  &dynamodb.AttributeValue{N: aws.String("1.5")},
+				"Temp":    &dynamodb.AttributeValue{N: aws.String("21.5")},
+				"Wait":    &dynamodb.AttributeValue{N: aws.String("90000000000")},
+			},
+		},
+		{
+			Opts: []EncoderOption{
+				EncodeTagKey("ddb"),
+				EncodeNaming(upper),
+				NullEmpty(),
+				EncodeNumbersAs(TypeString),
+				EncodeBoolsAs(TypeNumber),
+				SlicesAsLists(),
+				EncodeTypeWith(reflect.TypeOf(celsius(0)), temp),
+			},
+			Expect: map[string]*dynamodb.AttributeValue{
+				"id":     &dynamodb.AttributeValue{S: aws.String("a")},
+				"COUNT":  &dynamodb.AttributeValue{S: aws.String("2")},
+				"ACTIVE": &dynamodb.AttributeValue{N: aws.String("1")},
+				"TAGS": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+					&dynamodb.AttributeValue{S: aws.String("x")},
+				}},
+				"NOTE":   &dynamodb.AttributeValue{NULL: aws.Bool(true)},
+				"AMOUNT": &dynamodb.AttributeValue{S: aws.String("1.5")},
+				"TEMP":   &dynamodb.AttributeValue{S: aws.String("21.5C")},
+				"WAIT":   &dynamodb.AttributeValue{S: aws.String("1m30s")},
+			},
+		},
+		{
+			Opts: []EncoderOption{EncodeBoolsAs(TypeString)},
+			Expect: map[string]*dynamodb.AttributeValue{
+				"ignored": &dynamodb.AttributeValue{S: aws.String("a")},
+				"Count":   &dynamodb.AttributeValue{N: aws.String("2")},
+				"Active":  &dynamodb.AttributeValue{S: aws.String("true")},
+				"Tags":    &dynamodb.AttributeValue{SS: []*string{aws.String("x")}},
+				"Amount":  &dynamodb.AttributeValue{N: aws.String("1.5")},
+				"Temp":    &dynamodb.AttributeValue{N: aws.String("21.5")},
+				"Wait":    &dynamodb.AttributeValue{N: aws.String("90000000000")},
+			},
+		},
+	}
+
+	for i, test := range tests {
+
+		have, err := NewEncoder(test.Opts...).ConvertToAttributes(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(test.Expect, have) {
+			t.Errorf("%d: Expect=%v, Have=%v", i, test.Expect, have)
+		}
+	}
+
+	// types a value cannot be stored as are refused
+	invalid := map[string]func(){
+		"EncodeNumbersAs": func() { EncodeNumbersAs(TypeBool) },
+		"EncodeBoolsAs":   func() { EncodeBoolsAs(TypeList) },
+		"DecodeNumbersAs": func() { DecodeNumbersAs(TypeNone) },
+		"DecodeBoolsAs":   func() { DecodeBoolsAs(TypeMap) },
+	}
+	for name, option := range invalid {
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Expect=panic", name)
+				}
+			}()
+			option()
+		}()
+	}
+}
+
+func TestDecoderOptions(t *testing.T) {
+	t.Parallel()
+
+	temp := func(av *dynamodb.AttributeValue, v interface{}) error {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(*av.S, "C"), 64)
+		*v.(*celsius) = celsius(f)
+		return err
+	}
+
+	legacy := map[string]*dynamodb.AttributeValue{
+		"id":     &dynamodb.AttributeValue{S: aws.String("a")},
+		"count":  &dynamodb.AttributeValue{S: aws.String("2")},
+		"active": &dynamodb.AttributeValue{N: aws.String("1")},
+		"tags": &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{
+			&dynamodb.AttributeValue{S: aws.String("x")},
+		}},
+		"amount": &dynamodb.AttributeValue{S: aws.String("1.5")},
+		"temp":   &dynamodb.AttributeValue{S: aws.String("21.5C")},
+		"wait":   &dynamodb.AttributeValue{S: aws.String("1m30s")},
+	}
+
+	expect := optionsStruct{
+		ID:     "a",
+		Count:  2,
+		Active: true,
+		Tags:   []string{"x"},
+		Amount: Number("1.5"),
+		Temp:   21.5,
+		Wait:   90 * time.Second,
+	}
+
+	d := NewDecoder(
+		DecodeTagKey("ddb"),
+		DecodeNaming(strings.ToLower),
+		StrictTypes(),
+		DecodeNumbersAs(TypeString),
+		DecodeBoolsAs(TypeNumber),
+		DecodeTypeWith(reflect.TypeOf(celsius(0)), temp),
+	)
+
+	var have optionsStruct
+	if err := d.ConvertFromAttributes(legacy, &have); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	// without them, strict decoding fails on the legacy representations
+	d = NewDecoder(DecodeTagKey("ddb"), DecodeNaming(strings.ToLower), StrictTypes())
+	if err := d.ConvertFromAttributes(legacy, &have); !errors.Is(err, ErrInvalidConversion) {
+		t.Errorf("Expect=%v, Have=%v", ErrInvalidConversion, err)
+	}
+}
+
+func TestNamingStrategies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		GoName string
+		Expect [5]string
+	}{
+		{"ID", [5]string{"ID", "id", "id", "id", "ID"}},
+		{"UserID", [5]string{"UserID", "userID", "user_id", "user-id", "USER_ID"}},
+		{"HTTPServer", [5]string{"HTTPServer", "httpServer", "http_server", "http-server", "HTTP_SERVER"}},
+		{"Address2", [5]string{"Address2", "address2", "address2", "address2", "ADDRESS2"}},
+		{"V2Name", [5]string{"V2Name", "v2Name", "v2_name", "v2-name", "V2_NAME"}},
+		{"Created_At", [5]string{"Created_At", "createdAt", "created_at", "created-at", "CREATED_AT"}},
+	}
+
+	strategies := []NamingStrategy{Identity, LowerCamelCase, SnakeCase, KebabCase, ScreamingSnakeCase}
+	for _, test := range tests {
+
+		for i, naming := range strategies {
+
+			if have := naming(test.GoName); have != test.Expect[i] {
+				t.Errorf("%s %d: Expect=%s, Have=%s", test.GoName, i, test.Expect[i], have)
+			}
+		}
+	}
+
+	from := namingStruct{
+		UserID:     "u",
+		HTTPServer: "h",
+		Tagged:     "t",
+		JSON:       "j",
+	}
+
+	expect := map[string]*dynamodb.AttributeValue{
+		"user_id":     &dynamodb.AttributeValue{S: aws.String("u")},
+		"http_server": &dynamodb.AttributeValue{S: aws.String("h")},
+		"TaggedName":  &dynamodb.AttributeValue{S: aws.String("t")},
+		"jsonName":    &dynamodb.AttributeValue{S: aws.String("j")},
+	}
+
+	// explicit names in tags are kept
+	have, err := NewEncoder(EncodeNaming(SnakeCase)).ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, have) {
+		t.Errorf("Expect=%v, Have=%v", expect, have)
+	}
+
+	var to namingStruct
+	if err := NewDecoder(DecodeNaming(SnakeCase), DisallowUnknownFields()).ConvertFromAttributes(have, &to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	// the snake case names are unknown to other strategies
+	err = NewDecoder(DecodeNaming(KebabCase), DisallowUnknownFields()).ConvertFromAttributes(have, &to)
+	if !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Expect=%v, Have=%v", ErrUnknownField, err)
+	}
+}
+
+func TestCachedTypeFields(t *testing.T) {
+	t.Parallel()
+
+	typ := reflect.TypeOf(benchItem{})
+
+	var wg sync.WaitGroup
+	fields := make([]*structFields, 8)
+	for i := range fields {
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fields[i] = defaultFields.cachedTypeFields(typ)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, f := range fields {
+		if f != fields[0] {
+			t.Fatal("Expect every caller to share the cached fields")
+		}
+	}
+
+	// funcs are never deeply equal, so the codecs are compared apart
+	list := make([]field, len(fields[0].list))
+	for i, f := range fields[0].list {
+
+		if f.encode == nil || f.decode == nil {
+			t.Errorf("%s: Expect encode and decode funcs", f.goName)
+		}
+		f.encode, f.decode = nil, nil
+		list[i] = f
+	}
+	if !reflect.DeepEqual(list, typeFields(typ, "dynamodb", nil)) {
+		t.Errorf("Expect=%v, Have=%v", typeFields(typ, "dynamodb", nil), list)
+	}
+
+	if f, ok := fields[0].lookup("USERID"); !ok || f.goName != "UserID" {
+		t.Errorf("Expect=UserID, Have=%v", f.goName)
+	}
+}
+
+func TestTypeCodecs(t *testing.T) {
+	t.Parallel()
+
+	s := "ptr"
+	from := &codecStruct{
+		String:   "a",
+		Int:      -2,
+		Float:    1.5,
+		Bool:     true,
+		Strings:  []string{"b", "c"},
+		Address:  benchAddress{Street: "1 Main St", City: "Springfield", Zip: "12345"},
+		Ptr:      &s,
+		Time:     time.Date(2016, time.March, 1, 12, 30, 15, 0, time.UTC),
+		Duration: 90 * time.Second,
+		Number:   Number("12345678901234567890"),
+		Level:    levelHigh,
+		Money:    money(1234),
+		Point:    point{Lat: 1, Lng: 2},
+		Any:      "any",
+	}
+
+	// the cached codecs of each type
+	item, err := ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := new(codecStruct)
+	if err := ConvertFromAttributes(item, to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+
+	// funcs given for a type replace its cached codecs
+	durationType := reflect.TypeOf(time.Duration(0))
+	e := NewEncoder(EncodeTypeWith(durationType, func(v interface{}) (*dynamodb.AttributeValue, error) {
+		return &dynamodb.AttributeValue{S: aws.String(v.(time.Duration).String())}, nil
+	}))
+	d := NewDecoder(DecodeTypeWith(durationType, func(av *dynamodb.AttributeValue, v interface{}) error {
+		if av.S == nil {
+			return ErrInvalidConversion
+		}
+		var err error
+		*v.(*time.Duration), err = time.ParseDuration(*av.S)
+		return err
+	}))
+
+	item, err = e.ConvertToAttributes(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := item["Duration"]; have == nil || have.S == nil || *have.S != "1m30s" {
+		t.Errorf("Expect=%v, Have=%v", "1m30s", have)
+	}
+	to = new(codecStruct)
+	if err := d.ConvertFromAttributes(item, to); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(from, to) {
+		t.Errorf("Expect=%v, Have=%v", from, to)
+	}
+}
+
+func TestFieldByName(t *testing.T) {
+	t.Parallel()
+
+	have := &taggedStruct{
+		Tag1: "tag1",
+		Tag2: "tag2",
+		Tag3: "tag3",
+		Tag4: "tag4",
+		Tag5: "tag5",
+		Tag6: "tag6",
+		Tag7: "tag7",
+	}
+	tests := []struct {
+		Tag    string
+		Expect string
+	}{
+		{
+			Tag:    "Tag1",
+			Expect: "tag1",
+		},
+		{
+			Tag:    "untag1",
+			Expect: "tag1",
+		},
+		{
+			Tag:    "ddbtag3",
+			Expect: "tag3",
+		},
+		{
+			Tag:    "untag3",
+			Expect: "",
+		},
+		{
+			Tag:    "Tag4",
+			Expect: "",
+		},
+		{
+			Tag:    "untag5",
+			Expect: "tag5",
+		},
+		{
+			Tag:    "untag5,omitempty",
+			Expect: "",
+		},
+		{
+			Tag:    "Tag6",
+			Expect: "",
+		},
+		{
+			Tag:    "-",
+			Expect: "tag7",
+		},
+	}
+
+	for _, tt := range tests {
+
+		var value string
+		if v, _ := defaultFields.fieldByName(reflect.ValueOf(have), tt.Tag); v.IsValid() {
+			value = v.String()
+		}
+		if value != tt.Expect {
+			t.Errorf("Tag=%s, Expect=%s, Have=%s", tt.Tag, tt.Expect, value)
+		}
+	}
+}
+
+func TestFieldByNamePrecedence(t *testing.T) {
+	t.Parallel()
+
+	have := &aliasStruct{
+		UserID: "user",
+		Other:  "other",
+		Name:   "name",
+		Title:  "title",
+	}
+	tests := []struct {
+		Name   string
+		Expect string
+	}{
+		// an exact match on the attribute name wins over the Go name
+		{
+			Name:   "UserID",
+			Expect: "other",
+		},
+		{
+			Name:   "userId",
+			Expect: "user",
+		},
+		{
+			Name:   "user_id",
+			Expect: "user",
+		},
+		{
+			Name:   "uid",
+			Expect: "user",
+		},
+		// and an exact match wins over an alias
+		{
+			Name:   "Name",
+			Expect: "name",
+		},
+		{
+			Name:   "heading",
+			Expect: "title",
+		},
+		{
+			Name:   "NAME",
+			Expect: "name",
+		},
+		{
+			Name:   "userid_",
+			Expect: "",
+		},
+	}
+
+	for _, tt := range tests {
+
+		var value string
+		if v, _ := defaultFields.fieldByName(reflect.ValueOf(have), tt.Name); v.IsValid() {
+			value = v.String()
+		}
+		if value != tt.Expect {
+			t.Errorf("Name=%s, Expect=%s, Have=%s", tt.Name, tt.Expect, value)
+		}
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"USERID": &dynamodb.AttributeValue{
+			S: aws.String("user"),
+		},
+		"heading": &dynamodb.AttributeValue{
+			S: aws.String("title"),
+		},
+	}
+
+	to := new(struct {
+		UserID string `json:"userId"`
+		Title  string `dynamodb:"title,alias=heading"`
+	})
+	if err := ConvertFromAttributes(item, to); err != nil {
+		t.Fatal(err)
+	}
+	if to.UserID != "user" || to.Title != "title" {
+		t.Errorf("Expect=user title, Have=%v", to)
+	}
+}
+
+func verify(to, expect interface{}, t *testing.T) {
+
+	ev := reflect.ValueOf(expect)
+	if ev.Kind() == reflect.Ptr || ev.Kind() == reflect.Interface {
+		ev = ev.Elem()
+	}
+	et := ev.Type()
+
+	tv := reflect.ValueOf(to)
+	if tv.Kind() == reflect.Ptr || tv.Kind() == reflect.Interface {
+		tv = tv.Elem()
+	}
+
+	for i := 0; i < ev.NumField(); i++ {
+
+		ef := ev.Field(i)
+		tf := tv.FieldByName(et.Field(i).Name)
+		ek := ef.Kind()
+		if ek == reflect.Ptr {
+			ek = ef.Elem().Kind()
+		}
+
+		switch ek {
+
+		case reflect.Struct:
+			verify(tf.Interface(), ef.Interface(), t)
+
+		case reflect.Slice:
+
+			if ef.Len() != tf.Len() {
+
+				t.Errorf(
+					"%s: Len Expect=%d, Received=%d",
+					et.Field(i).Name,
+					ef.Len(),
+					tf.Len(),
+				)
+				continue
+			}
+			for i := 0; i < ef.Len(); i++ {
+
+				efi := ef.Index(i)
+				tfi := tf.Index(i)
+
+				if efi.Kind() == reflect.Slice {
+
+					if !reflect.DeepEqual(efi.Interface(), tfi.Interface()) {
+						t.Errorf(
+							"%s: Expect=%v, Received=%v",
+							et.Field(i).Name,
+							efi.Interface(),
+							tfi.Interface(),
+						)
+						continue
+					}
+
+				} else {
+
+					if efi.Interface() != tfi.Interface() {
+
+						t.Errorf(
+							"%s: Expect=%v, Received=%v",
+							et.Field(i).Name,
+							efi.Interface(),
+							tfi.Interface(),
+						)
+					}
+				}
+			}
+
+		default:
+			if ef.Interface() != tf.Interface() {
+
+				t.Errorf(
+					"%s: Expect=%v, Received=%v",
+					et.Field(i).Name,
+					ef.Interface(),
+					tf.Interface(),
+				)
+			}
+		}
+	}
+}
+
+func BenchmarkConvertToAttributes(b *testing.B) {
+
+	from := newBenchItem()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ConvertToAttributes(from); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertFromAttributes(b *testing.B) {
+
+	item, err := ConvertToAttributes(newBenchItem())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ConvertFromAttributes(item, new(benchItem)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTypeFields(b *testing.B) {
+
+	t := reflect.TypeOf(benchItem{})
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			typeFields(t, "dynamodb", nil)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			defaultFields.cachedTypeFields(t)
+		}
+	})
+}
+
+func BenchmarkEncodeFields(b *testing.B) {
+
+	from := reflect.ValueOf(newBenchItem()).Elem()
+	t := from.Type()
+
+	encode := func(b *testing.B, list func() []field, codecs bool) {
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+
+			for _, fld := range list() {
+
+				encode := fld.encode
+				if !codecs {
+					encode = (*Encoder).createAttribute
+				}
+				if _, err := defaultEncoder.createField(from.FieldByIndex(fld.index), fld.fieldTag, encode); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	// the fields of every item were read before they were cached
+	b.Run("uncached", func(b *testing.B) {
+		encode(b, func() []field { return typeFields(t, "dynamodb", nil) }, false)
+	})
+
+	b.Run("createAttribute", func(b *testing.B) {
+		encode(b, func() []field { return defaultFields.cachedTypeFields(t).list }, false)
+	})
+
+	b.Run("codecs", func(b *testing.B) {
+		encode(b, func() []field { return defaultFields.cachedTypeFields(t).list }, true)
+	})
+}
+
+func BenchmarkDecodeFields(b *testing.B) {
+
+	item, err := ConvertToAttributes(newBenchItem())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	decode := func(b *testing.B, fieldByName func(v reflect.Value, name string) (reflect.Value, field), codecs bool) {
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+
+			to := reflect.ValueOf(new(benchItem)).Elem()
+			for key, av := range item {
+
+				toField, f := fieldByName(to, key)
+				decode := f.decode
+				if !codecs {
+					decode = (*Decoder).setAttribute
+				}
+				if err := decode(defaultDecoder, av, &toField, f.fieldTag); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		decode(b, uncachedFieldByName, false)
+	})
+
+	b.Run("setAttribute", func(b *testing.B) {
+		decode(b, defaultFields.fieldByName, false)
+	})
+
+	b.Run("codecs", func(b *testing.B) {
+		decode(b, defaultFields.fieldByName, true)
+	})
+}
+
+// uncachedFieldByName finds a field as fieldByName did before fields were
+// cached, reading every field of the struct for each attribute
+func uncachedFieldByName(v reflect.Value, name string) (reflect.Value, field) {
+
+	fields := typeFields(v.Type(), "dynamodb", nil)
+	matches := []func(f field) bool{
+		func(f field) bool { return f.name == name },
+		func(f field) bool { return f.goName == name },
+		func(f field) bool {
+			for _, a := range f.aliases {
+				if a == name {
+					return true
+				}
+			}
+			return false
+		},
+		func(f field) bool { return strings.EqualFold(f.name, name) },
+	}
+
+	for _, match := range matches {
+
+		for _, f := range fields {
+
+			if match(f) {
+				return allocFieldByIndex(v, f.index), f
+			}
+		}
+	}
+
+	return reflect.Value{}, field{}
+}
+
+func newBenchItem() *benchItem {
+
+	return &benchItem{
+		ID:        "0f8fad5b-d9cb-469f-a165-70867728950e",
+		UserID:    "user",
+		Name:      "name",
+		Email:     "name@example.com",
+		Age:       42,
+		Score:     98.6,
+		Active:    true,
+		Tags:      []string{"a", "b", "c"},
+		Counts:    map[string]int{"a": 1, "b": 2},
+		CreatedAt: time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Address: benchAddress{
+			Street: "1 Main St",
+			City:   "Springfield",
+			Zip:    "12345",
+		},
+		Orders: []benchOrder{
+			{SKU: "sku-1", Quantity: 1, Price: 9.99},
+			{SKU: "sku-2", Quantity: 2, Price: 19.99},
+		},
+	}
+}
+
+type primitivesStruct struct {
+	TString      string
 	TBool        bool
 	TInt         int
 	TInt8        int8
@@ -536,7 +3171,455 @@ type subNestedStruct struct {
 	TFloat32 float32
 }
 
+type mapStruct struct {
+	TMap        map[string]int
+	TNested     map[string]map[string]string
+	TJSON       map[string]int  `dynamodb:",json"`
+	TStructJSON subNestedStruct `dynamodb:",json"`
+}
+
+type documentStruct struct {
+	TString    string
+	TStruct    *subNestedStruct
+	TMap       map[string]int
+	TNested    map[string]map[string]string
+	TInterface interface{}
+}
+
+type listStruct struct {
+	TStructs []subNestedStruct
+	TPtrs    []*subNestedStruct
+	TMaps    []map[string]string
+	TMixed   []interface{}
+	TArray   [2]subNestedStruct
+	TNested  [][]string
+	TInts    []int
+}
+
+// money marshals with a value receiver and unmarshals with a pointer receiver
+type money int64
+
+func (m money) MarshalDynamoDBAttributeValue() (*dynamodb.AttributeValue, error) {
+
+	return &dynamodb.AttributeValue{
+		S: aws.String(fmt.Sprintf("$%d.%02d", m/100, m%100)),
+	}, nil
+}
+
+func (m *money) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+
+	if av.S == nil {
+		return ErrInvalidConversion
+	}
+
+	var dollars, cents int64
+	if _, err := fmt.Sscanf(*av.S, "$%d.%02d", &dollars, &cents); err != nil {
+		return err
+	}
+	*m = money(dollars*100 + cents)
+	return nil
+}
+
+// point only marshals through a pointer receiver
+type point struct {
+	Lat, Lng int
+}
+
+func (p *point) MarshalDynamoDBAttributeValue() (*dynamodb.AttributeValue, error) {
+
+	return &dynamodb.AttributeValue{
+		NS: []*string{aws.String(strconv.Itoa(p.Lat)), aws.String(strconv.Itoa(p.Lng))},
+	}, nil
+}
+
+func (p *point) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+
+	if len(av.NS) != 2 {
+		return ErrInvalidConversion
+	}
+
+	var err error
+	if p.Lat, err = strconv.Atoi(*av.NS[0]); err != nil {
+		return err
+	}
+	p.Lng, err = strconv.Atoi(*av.NS[1])
+	return err
+}
+
+type marshalerStruct struct {
+	Price     money
+	Prices    []money
+	ByName    map[string]money
+	Location  point
+	Locations []point
+	Nested    subMarshalerStruct
+}
+
+type subMarshalerStruct struct {
+	Location *point
+}
+
+// marshalerItem converts itself into a whole item
+type marshalerItem struct {
+	ID string
+}
+
+func (i *marshalerItem) MarshalDynamoDBAttributeValue() (*dynamodb.AttributeValue, error) {
+
+	return &dynamodb.AttributeValue{
+		M: map[string]*dynamodb.AttributeValue{
+			"pk": &dynamodb.AttributeValue{
+				S: aws.String("ITEM#" + i.ID),
+			},
+		},
+	}, nil
+}
+
+func (i *marshalerItem) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+
+	pk, ok := av.M["pk"]
+	if !ok || pk.S == nil {
+		return ErrInvalidConversion
+	}
+	i.ID = strings.TrimPrefix(*pk.S, "ITEM#")
+	return nil
+}
+
+type level int
+
+const (
+	levelLow level = iota
+	levelHigh
+)
+
+func (l level) MarshalText() ([]byte, error) {
+
+	if l == levelHigh {
+		return []byte("high"), nil
+	}
+	return []byte("low"), nil
+}
+
+func (l *level) UnmarshalText(text []byte) error {
+
+	switch string(text) {
+
+	case "high":
+		*l = levelHigh
+
+	case "low":
+		*l = levelLow
+
+	default:
+		return ErrInvalidConversion
+	}
+	return nil
+}
+
+type digest [2]byte
+
+func (d digest) MarshalBinary() ([]byte, error) {
+	return d[:], nil
+}
+
+func (d *digest) UnmarshalBinary(data []byte) error {
+
+	if len(data) != len(d) {
+		return ErrInvalidConversion
+	}
+	copy(d[:], data)
+	return nil
+}
+
+type coord struct {
+	X, Y int
+}
+
+func (c coord) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", c.X, c.Y)), nil
+}
+
+func (c *coord) UnmarshalText(text []byte) error {
+
+	_, err := fmt.Sscanf(string(text), "%d,%d", &c.X, &c.Y)
+	return err
+}
+
+type label string
+
+func (l label) MarshalText() ([]byte, error) {
+	return []byte(l), nil
+}
+
+func (l *label) UnmarshalText(text []byte) error {
+
+	*l = label(text)
+	return nil
+}
+
+type textMarshalerStruct struct {
+	IP     net.IP
+	IPs    []net.IP
+	Level  level
+	Digest digest
+	Grid   map[coord]string
+}
+
+type timeStruct struct {
+	Created  time.Time
+	Expires  time.Time   `dynamodb:",unix"`
+	Seen     time.Time   `dynamodb:",unixmilli"`
+	Updated  time.Time   `dynamodb:",unixnano"`
+	Times    []time.Time `dynamodb:",unix"`
+	Timeout  time.Duration
+	Interval time.Duration `dynamodb:",string"`
+	Zero     time.Time
+}
+
+type keyFields struct {
+	PK string `dynamodb:"pk"`
+	SK string `dynamodb:"sk"`
+}
+
+type Audit struct {
+	CreatedBy string
+	Name      string
+	Version   int
+}
+
+type versionFields struct {
+	Version int
+}
+
+type Meta struct {
+	Source string
+}
+
+type embeddedStruct struct {
+	keyFields
+	*Audit
+	versionFields
+	Meta `dynamodb:"meta"`
+	Name string
+}
+
+type codecStruct struct {
+	String   string
+	Int      int
+	Float    float32
+	Bool     bool
+	Strings  []string
+	Address  benchAddress
+	Ptr      *string
+	Time     time.Time
+	Duration time.Duration
+	Number   Number
+	Level    level
+	Money    money
+	Point    point
+	Any      interface{}
+}
+
+type hiddenFields struct {
+	Label string
+}
+
+type unexportedEmbeddedStruct struct {
+	*hiddenFields
+}
+
+type numberStruct struct {
+	Price  Number
+	Prices []Number
+}
+
+type pointerStruct struct {
+	Int      *int
+	ZeroInt  *int
+	PtrPtr   **string
+	Nil      *int
+	Nullable *int `dynamodb:",nullable"`
+	Ints     []*int
+	ByName   map[string]*string
+	Time     *time.Time `dynamodb:",unix"`
+}
+
+type nullableStruct struct {
+	Note     string        `dynamodb:",nullable"`
+	Count    int           `dynamodb:",nullable"`
+	Flag     bool          `dynamodb:",omitempty"`
+	Duration time.Duration `dynamodb:",omitempty"`
+	Big      big.Int
+}
+
+type zeroArrayStruct struct {
+	Struct subNestedStruct
+	Array  [3]int
+}
+
+type zeroStruct struct {
+	Int      int
+	Bool     bool
+	Float    float64
+	Struct   subNestedStruct
+	Time     time.Time
+	List     []subNestedStruct
+	Map      map[string]int
+	Set      []string
+	NilList  []subNestedStruct
+	OmitInt  int               `dynamodb:",omitempty"`
+	OmitBool bool              `dynamodb:",omitempty"`
+	OmitTime time.Time         `dynamodb:",omitempty"`
+	OmitList []subNestedStruct `dynamodb:",omitempty"`
+	OmitMap  map[string]int    `dynamodb:",omitempty"`
+}
+
+type blob []byte
+
+type binaryStruct struct {
+	Bytes  []byte
+	Fixed  [4]byte
+	Named  blob
+	Empty  []byte
+	Nested [][]byte
+	Ints   [][]int
+	List   []byte `dynamodb:",list"`
+}
+
+type bigStruct struct {
+	Int   *big.Int
+	Float *big.Float
+	Rat   *big.Rat
+}
+
+type setStruct struct {
+	Strings StringSet
+	Numbers NumberSet
+	Binary  BinarySet
+	Ints    map[int]struct{}
+	Flags   map[string]bool `dynamodb:",set"`
+	Empty   StringSet
+	Tagged  []string `dynamodb:",set"`
+	List    []string `dynamodb:",list"`
+}
+
 type taggedStruct struct {
 	Tag1 string `json:"untag1"`
 	Tag2 string `json:"untag2"`
+	Tag3 string `dynamodb:"ddbtag3" json:"untag3"`
+	Tag4 string `dynamodb:"-"`
+	Tag5 string `json:"untag5,omitempty"`
+	Tag6 string `json:"-"`
+	Tag7 string `json:"-,"`
+}
+
+type orderList struct {
+	Orders []order `dynamodb:"orders"`
+}
+
+type order struct {
+	Items orderItem `dynamodb:"items"`
+}
+
+type orderItem struct {
+	SKU   int     `dynamodb:"sku"`
+	Price float64 `dynamodb:"price"`
+}
+
+type strictStruct struct {
+	ID    string `dynamodb:",required"`
+	Count int
+	Flag  bool `dynamodb:",string"`
+	Tags  []string
+}
+
+type strictSetStruct struct {
+	Blobs BinarySet
+}
+
+type celsius float64
+
+type optionsStruct struct {
+	ID     string `ddb:"id" dynamodb:"ignored"`
+	Count  int
+	Active bool
+	Tags   []string
+	Note   string
+	Amount Number
+	Temp   celsius
+	Wait   time.Duration
+}
+
+type namingStruct struct {
+	UserID     string
+	HTTPServer string
+	Tagged     string `dynamodb:"TaggedName"`
+	JSON       string `json:"jsonName"`
+}
+
+type strictTimeStruct struct {
+	Timeout  time.Duration
+	Interval time.Duration `dynamodb:",string"`
+	Amount   *big.Int
+	At       time.Time
+	Expires  time.Time `dynamodb:",unix"`
+}
+
+type aliasStruct struct {
+	UserID string `dynamodb:"userId,alias=user_id,alias=uid"`
+	Other  string `dynamodb:"UserID"`
+	Name   string
+	Title  string `dynamodb:"title,alias=Name,alias=heading"`
+}
+
+type jsonTaggedStruct struct {
+	Zip      int     `json:"zip,omitempty"`
+	Zero     int     `json:"zero,omitempty"`
+	Hidden   string  `json:"-"`
+	Dash     string  `json:"-,"`
+	Count    int64   `json:",string"`
+	Price    float64 `json:"price,string"`
+	Flag     bool    `json:"flag,string"`
+	Override string  `json:"-" dynamodb:"override"`
+}
+
+type dynamoTaggedStruct struct {
+	Name     string            `dynamodb:"name" json:"json_name"`
+	JSONOnly string            `json:"json_only"`
+	Skip     string            `dynamodb:"-"`
+	Empty    int               `dynamodb:"empty,omitempty"`
+	Count    int               `dynamodb:"count,string"`
+	Tags     []string          `dynamodb:"tags,list"`
+	IDs      []int             `dynamodb:"ids,set"`
+	Raw      string            `dynamodb:"raw,binary"`
+	Blob     []byte            `dynamodb:"blob,binary"`
+	Keys     []string          `dynamodb:"keys,binary"`
+	Opts     map[string]string `dynamodb:",omitempty"`
+}
+
+type benchItem struct {
+	ID        string `dynamodb:"id"`
+	UserID    string `dynamodb:"userId"`
+	Name      string `dynamodb:"name"`
+	Email     string `dynamodb:"email,omitempty"`
+	Age       int    `dynamodb:"age"`
+	Score     float64
+	Active    bool
+	Tags      []string       `dynamodb:"tags,list"`
+	Counts    map[string]int `dynamodb:"counts"`
+	CreatedAt time.Time      `dynamodb:"createdAt"`
+	Address   benchAddress   `dynamodb:"address"`
+	Orders    []benchOrder   `dynamodb:"orders"`
+}
+
+type benchAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	Zip    string `json:"zip"`
+}
+
+type benchOrder struct {
+	SKU      string  `dynamodb:"sku"`
+	Quantity int     `dynamodb:"quantity"`
+	Price    float64 `dynamodb:"price"`
 }
@@ -0,0 +1,206 @@
+package marshalddb
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// An Encoder converts Go values into AttributeValues. An Encoder is safe
+// for concurrent use once created.
+type Encoder struct {
+	omitEmpty     bool
+	nullEmpty     bool
+	collectErrors bool
+	slicesAsLists bool
+	numbers       Type
+	bools         Type
+	tagKey        string
+	naming        NamingStrategy
+	encoders      map[reflect.Type]EncodeFunc
+	fields        *fieldCache
+	// configured is set when the Encoder has any options, which the
+	// methods written by marshalddb-gen do not follow
+	configured bool
+}
+
+// EncodeFunc converts a value into an AttributeValue. Returning a nil
+// AttributeValue omits the value.
+type EncodeFunc func(v interface{}) (*dynamodb.AttributeValue, error)
+
+// An EncoderOption configures an Encoder
+type EncoderOption func(*Encoder)
+
+// OmitEmpty omits every struct field holding its zero value, such as a
+// struct or array whose elements are all zero, along with the empty values
+// omitted by the omitempty tag option.
+func OmitEmpty() EncoderOption {
+
+	return func(e *Encoder) {
+		e.omitEmpty = true
+	}
+}
+
+// NullEmpty writes a NULL attribute for every struct field holding a
+// value DynamoDB cannot store, such as an empty string or a nil pointer,
+// as though each were tagged nullable.
+func NullEmpty() EncoderOption {
+
+	return func(e *Encoder) {
+		e.nullEmpty = true
+	}
+}
+
+// SlicesAsLists stores slices and arrays of strings and numbers as L
+// attributes rather than as sets, unless they are tagged with the set
+// option. Byte slices are still stored as B attributes.
+func SlicesAsLists() EncoderOption {
+
+	return func(e *Encoder) {
+		e.slicesAsLists = true
+	}
+}
+
+// EncodeNumbersAs stores numbers as attributes of type typ, which is
+// either TypeNumber, the default, or TypeString to store them as though
+// every number, including a time.Duration, were tagged with the string
+// option. Members of sets are always stored in NS attributes. It panics
+// if typ is any other Type.
+func EncodeNumbersAs(typ Type) EncoderOption {
+
+	mustBeFormat("EncodeNumbersAs", typ, TypeNumber, TypeString)
+	return func(e *Encoder) {
+		e.numbers = typ
+	}
+}
+
+// EncodeBoolsAs stores bools as attributes of type typ, which is either
+// TypeBool, the default, TypeNumber to store them as "1" and "0", or
+// TypeString to store them as "true" and "false". It panics if typ is
+// any other Type.
+func EncodeBoolsAs(typ Type) EncoderOption {
+
+	mustBeFormat("EncodeBoolsAs", typ, TypeBool, TypeNumber, TypeString)
+	return func(e *Encoder) {
+		e.bools = typ
+	}
+}
+
+// EncodeTagKey reads the names and options of struct fields from the
+// struct tag key, rather than from their `dynamodb` tag. Fields without
+// that tag are still named by their `json` tag.
+func EncodeTagKey(key string) EncoderOption {
+
+	return func(e *Encoder) {
+		e.tagKey = key
+	}
+}
+
+// EncodeNaming names the attributes of struct fields without a name in
+// their struct tags by naming, rather than by their Go names.
+func EncodeNaming(naming NamingStrategy) EncoderOption {
+
+	return func(e *Encoder) {
+		e.naming = naming
+	}
+}
+
+// EncodeTypeWith converts values of type t with fn, rather than by the
+// rules of the Encoder. fn is passed a value of type t, which should not
+// be a pointer type since pointers are followed to the values they hold.
+func EncodeTypeWith(t reflect.Type, fn EncodeFunc) EncoderOption {
+
+	return func(e *Encoder) {
+		if e.encoders == nil {
+			e.encoders = make(map[reflect.Type]EncodeFunc)
+		}
+		e.encoders[t] = fn
+	}
+}
+
+// CollectEncodeErrors keeps encoding after a value fails to encode,
+// returning every value that could be encoded along with an Errors
+// listing each one that could not.
+func CollectEncodeErrors() EncoderOption {
+
+	return func(e *Encoder) {
+		e.collectErrors = true
+	}
+}
+
+// defaultEncoder backs the package level encoding functions
+var defaultEncoder = NewEncoder()
+
+// NewEncoder returns an Encoder configured by opts
+func NewEncoder(opts ...EncoderOption) *Encoder {
+
+	e := new(Encoder)
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.fields = fieldsFor(e.tagKey, e.naming)
+	e.configured = len(opts) != 0
+	return e
+}
+
+// Marshal converts any Go value into a single AttributeValue
+func (e *Encoder) Marshal(v interface{}) (*dynamodb.AttributeValue, error) {
+
+	var (
+		fi  *dynamodb.AttributeValue
+		err error
+	)
+
+	if v != nil {
+		fi, err = e.createAttribute(reflect.ValueOf(v), fieldTag{})
+		if err != nil {
+			err = encodeError(err, "", reflect.TypeOf(v))
+			if !e.collectErrors {
+				return nil, err
+			}
+		}
+	}
+
+	if fi == nil {
+		fi = &dynamodb.AttributeValue{
+			NULL: aws.Bool(true),
+		}
+	}
+
+	return fi, err
+}
+
+// ConvertToAttributes converts a struct or map into an item
+func (e *Encoder) ConvertToAttributes(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+
+	to := make(map[string]*dynamodb.AttributeValue)
+
+	fi, err := e.Marshal(v)
+	if fi == nil {
+		return to, err
+	}
+	if fi.M == nil {
+		if err == nil {
+			err = encodeError(ErrConversionNotSupported, "", reflect.TypeOf(v))
+		}
+		return to, err
+	}
+
+	// with CollectEncodeErrors, the item holds every attribute that could
+	// be encoded
+	return fi.M, err
+}
+
+// mustBeFormat panics unless typ is one of the types the option can
+// store a value as
+func mustBeFormat(option string, typ Type, supported ...Type) {
+
+	for _, t := range supported {
+		if typ == t {
+			return
+		}
+	}
+	panic("marshalddb: " + option + " does not support type " + strconv.Quote(typ.String()))
+}
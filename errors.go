@@ -1,6 +1,13 @@
 package marshalddb
 
-import "errors"
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
 
 var (
 	// ErrNilTarget if a target interface is a nil pointer
@@ -12,6 +19,9 @@ var (
 	ErrInvalidJSON = errors.New("Invalid JSON")
 	// ErrInvalidStringForNumber if unable to reflect from a string to an number
 	ErrInvalidStringForNumber = errors.New("Invalid String Conversion")
+	// ErrNumberRange if a number has more significant digits, or a greater
+	// or smaller magnitude, than DynamoDB can store
+	ErrNumberRange = errors.New("Number Out Of Range")
 	// ErrNumericOverflow if conversion to target numeric type will cause overflow
 	ErrNumericOverflow = errors.New("Numeric Overflow")
 	// ErrConversionNotSupported if a conversion from an AttributeValue
@@ -19,4 +29,230 @@ var (
 	ErrConversionNotSupported = errors.New("Unsupported Conversion")
 	// ErrInvalidConversion if an AttributeValue type reflection is not possible
 	ErrInvalidConversion = errors.New("Invalid Conversion")
+	// ErrUnknownField if an item holds an attribute without a matching
+	// field, when decoding with DisallowUnknownFields
+	ErrUnknownField = errors.New("Unknown Field")
+	// ErrMissingRequiredField if an item does not hold a field tagged
+	// required, when decoding with EnforceRequired
+	ErrMissingRequiredField = errors.New("Missing Required Field")
+	// ErrDuplicateSetMember if a field tagged as a set holds the same
+	// member more than once, which DynamoDB does not allow
+	ErrDuplicateSetMember = errors.New("Duplicate Set Member")
+	// ErrUnexportedEmbeddedPointer if an attribute is read into a field
+	// promoted through a nil pointer to an unexported embedded struct,
+	// which cannot be allocated
+	ErrUnexportedEmbeddedPointer = errors.New("Cannot Set Embedded Pointer To Unexported Struct")
 )
+
+// An UnmarshalTypeError describes an attribute that could not be decoded
+// into a Go value. It matches the sentinel error it was caused by, such as
+// ErrNumericOverflow, with errors.Is.
+type UnmarshalTypeError struct {
+	// Path is the attribute's location in the item, such as orders[3].items.sku
+	Path string
+	// AttributeType is the type of the attribute, such as TypeNumber
+	AttributeType Type
+	// GoType is the type of the value the attribute was decoded into
+	GoType reflect.Type
+	// Value is the attribute's value if it is an S, N or BOOL
+	Value string
+	// Err is the cause of the error
+	Err error
+}
+
+func (e *UnmarshalTypeError) Error() string {
+
+	msg := "marshalddb: cannot decode"
+	if e.AttributeType != TypeNone {
+		msg += " " + e.AttributeType.String()
+	}
+	msg += " attribute"
+	if e.Path != "" {
+		msg += " " + e.Path
+	}
+	if e.Value != "" {
+		msg += " (" + strconv.Quote(e.Value) + ")"
+	}
+	if e.GoType != nil {
+		msg += " into " + e.GoType.String()
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Is reports whether target is the error that caused e
+func (e *UnmarshalTypeError) Is(target error) bool {
+	return e.Err == target
+}
+
+// Unwrap returns the error that caused e
+func (e *UnmarshalTypeError) Unwrap() error {
+	return e.Err
+}
+
+// A MarshalError describes a Go value that could not be encoded into an
+// attribute. It matches the sentinel error it was caused by, such as
+// ErrInvalidFloat, with errors.Is.
+type MarshalError struct {
+	// Path is the attribute's location in the item, such as orders[3].items.sku
+	Path string
+	// GoType is the type of the value that could not be encoded
+	GoType reflect.Type
+	// Reason is the cause of the error
+	Reason error
+}
+
+func (e *MarshalError) Error() string {
+
+	msg := "marshalddb: cannot encode"
+	if e.GoType != nil {
+		msg += " " + e.GoType.String()
+	}
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg + ": " + e.Reason.Error()
+}
+
+// Is reports whether target is the error that caused e
+func (e *MarshalError) Is(target error) bool {
+	return e.Reason == target
+}
+
+// Unwrap returns the error that caused e
+func (e *MarshalError) Unwrap() error {
+	return e.Reason
+}
+
+// Errors is every error met while converting a value with
+// CollectEncodeErrors or CollectDecodeErrors, in the order they were met:
+// struct fields in order, list and set members by index and the keys of
+// maps and items sorted by name.
+// It matches any of its errors with errors.Is and errors.As.
+type Errors []error
+
+func (e Errors) Error() string {
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors held by e
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// add appends err to e, or each of its errors if it is an Errors itself
+func (e Errors) add(err error) Errors {
+
+	if errs, ok := err.(Errors); ok {
+		return append(e, errs...)
+	}
+	return append(e, err)
+}
+
+// err returns e as an error, or nil if it is empty
+func (e Errors) err() error {
+
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// decodeError adds the path segment seg to err, first wrapping err in an
+// UnmarshalTypeError describing attr and t if it is not one already.
+// The path of each error within an Errors is extended in turn.
+func decodeError(err error, seg string, attr *dynamodb.AttributeValue, t reflect.Type) error {
+
+	if errs, ok := err.(Errors); ok {
+		for i := range errs {
+			errs[i] = decodeError(errs[i], seg, attr, t)
+		}
+		return errs
+	}
+
+	e, ok := err.(*UnmarshalTypeError)
+	if !ok {
+
+		e = &UnmarshalTypeError{
+			GoType: t,
+			Err:    err,
+		}
+		if attr != nil {
+			e.AttributeType = AttributeType(attr)
+			e.Value = scalarValue(attr)
+		}
+	}
+
+	e.Path = joinPath(seg, e.Path)
+	return e
+}
+
+// encodeError adds the path segment seg to err, first wrapping err in a
+// MarshalError describing t if it is not one already.
+// The path of each error within an Errors is extended in turn.
+func encodeError(err error, seg string, t reflect.Type) error {
+
+	if errs, ok := err.(Errors); ok {
+		for i := range errs {
+			errs[i] = encodeError(errs[i], seg, t)
+		}
+		return errs
+	}
+
+	e, ok := err.(*MarshalError)
+	if !ok {
+
+		e = &MarshalError{
+			GoType: t,
+			Reason: err,
+		}
+	}
+
+	e.Path = joinPath(seg, e.Path)
+	return e
+}
+
+// joinPath prefixes path with the attribute name or list index seg
+func joinPath(seg, path string) string {
+
+	switch {
+
+	case seg == "":
+		return path
+
+	case path == "":
+		return seg
+
+	case path[0] == '[':
+		return seg + path
+	}
+
+	return seg + "." + path
+}
+
+// indexSegment is the path segment of a list element
+func indexSegment(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+// scalarValue returns the value of an S, N or BOOL attribute as a string
+func scalarValue(attr *dynamodb.AttributeValue) string {
+
+	switch {
+
+	case attr.S != nil:
+		return *attr.S
+
+	case attr.N != nil:
+		return *attr.N
+
+	case attr.BOOL != nil:
+		return strconv.FormatBool(*attr.BOOL)
+	}
+
+	return ""
+}
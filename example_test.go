@@ -54,6 +54,29 @@ func ExampleConvertToAttributes() {
 	}
 }
 
+func ExampleMarshal() {
+
+	av, err := marshalddb.Marshal([]interface{}{"abc", 18104})
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(*av.L[0].S, *av.L[1].N)
+		// Output: abc 18104
+	}
+}
+
+func ExampleUnmarshal() {
+
+	var zip int
+	err := marshalddb.Unmarshal(&dynamodb.AttributeValue{N: aws.String("18104")}, &zip)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(zip)
+		// Output: 18104
+	}
+}
+
 type address struct {
 	ID        string `json:"id"`
 	To        string `json:"attention"`
@@ -1,60 +1,214 @@
 package marshalddb
 
 import (
-	"reflect"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
-/*
-Attempts to extract the first non-zero value within an AttributeValue.
+// Type is the type of an AttributeValue, given by which of its members is set
+type Type int
+
+// The types of AttributeValue
+const (
+	// TypeNone is the type of an AttributeValue without any member set
+	TypeNone Type = iota
+	TypeBinary
+	TypeBool
+	TypeBinarySet
+	TypeList
+	TypeMap
+	TypeNumber
+	TypeNumberSet
+	TypeNull
+	TypeString
+	TypeStringSet
+)
 
-So given &dynamodb.AttributeValue{ S: aws.String("") } will return
-("S", reflect.ValueOf(dynamodb.AttributeValue.S))
-*/
+var typeNames = [...]string{
+	TypeNone:      "",
+	TypeBinary:    "B",
+	TypeBool:      "BOOL",
+	TypeBinarySet: "BS",
+	TypeList:      "L",
+	TypeMap:       "M",
+	TypeNumber:    "N",
+	TypeNumberSet: "NS",
+	TypeNull:      "NULL",
+	TypeString:    "S",
+	TypeStringSet: "SS",
+}
+
+// String returns the name DynamoDB gives the type, such as "S" or "NS"
+func (t Type) String() string {
+
+	if t < 0 || int(t) >= len(typeNames) {
+		return "Type(" + strconv.Itoa(int(t)) + ")"
+	}
+	return typeNames[t]
+}
+
+// AttributeType returns the type of av, which is the first of its members
+// that is set in the order B, BOOL, BS, L, M, N, NS, NULL, S and SS.
+// Empty sets are not considered to be set, while empty lists and maps are.
+func AttributeType(av *dynamodb.AttributeValue) Type {
+
+	switch {
+
+	case av == nil:
+		return TypeNone
+
+	case av.B != nil:
+		return TypeBinary
+
+	case av.BOOL != nil:
+		return TypeBool
+
+	case len(av.BS) != 0:
+		return TypeBinarySet
+
+	case av.L != nil:
+		return TypeList
+
+	case av.M != nil:
+		return TypeMap
+
+	case av.N != nil:
+		return TypeNumber
+
+	case len(av.NS) != 0:
+		return TypeNumberSet
+
+	case av.NULL != nil:
+		return TypeNull
+
+	case av.S != nil:
+		return TypeString
+
+	case len(av.SS) != 0:
+		return TypeStringSet
+	}
 
-func extractAttribute(attr *dynamodb.AttributeValue) (string, reflect.Value) {
+	return TypeNone
+}
+
+// IsNull reports whether av holds no value, either because it is nil or
+// because it is a NULL attribute
+func IsNull(av *dynamodb.AttributeValue) bool {
 
-	return extractAttributeFromValue(reflect.ValueOf(attr).Elem())
+	return av == nil || (av.NULL != nil && *av.NULL)
 }
 
-func extractAttributeFromValue(attrVal reflect.Value) (string, reflect.Value) {
+/*
+Converts an AttributeValue into its natural Go representation:
+
+	S    string
+	N    float64, or Number when the Decoder uses numbers
+	BOOL bool
+	NULL nil
+	B    []byte
+	SS   []string
+	NS   []float64, or []Number when the Decoder uses numbers
+	BS   [][]byte
+	L    []interface{}
+	M    map[string]interface{}
+*/
+
+func (d *Decoder) attributeInterface(attr *dynamodb.AttributeValue) (interface{}, error) {
+
+	switch AttributeType(attr) {
 
-	var (
-		fieldName    string
-		zeroFieldVal = reflect.Zero(reflect.TypeOf(fieldName))
-	)
+	case TypeString:
+		return *attr.S, nil
 
-	attrType := attrVal.Type()
-	for i := 0; i < attrVal.NumField(); i++ {
+	case TypeNumber:
+		return d.number(*attr.N)
 
-		f := attrVal.Field(i)
-		if f.Kind() == reflect.Ptr {
-			f = f.Elem()
+	case TypeBool:
+		return *attr.BOOL, nil
+
+	case TypeBinary:
+		return attr.B, nil
+
+	case TypeStringSet:
+		ss := make([]string, len(attr.SS))
+		for i, s := range attr.SS {
+			ss[i] = *s
+		}
+		return ss, nil
+
+	case TypeNumberSet:
+		if d.useNumber {
+			ns := make([]Number, len(attr.NS))
+			for i, s := range attr.NS {
+				if _, err := d.number(*s); err != nil {
+					return nil, err
+				}
+				ns[i] = Number(*s)
+			}
+			return ns, nil
 		}
 
-		if !f.IsValid() {
-			continue
+		ns := make([]float64, len(attr.NS))
+		for i, s := range attr.NS {
+			n, err := d.number(*s)
+			if err != nil {
+				return nil, err
+			}
+			ns[i] = n.(float64)
 		}
+		return ns, nil
 
-		fieldName = attrType.Field(i).Name
-		// Find first non-zero value
-		switch fieldName {
+	case TypeBinarySet:
+		return attr.BS, nil
 
-		case "B":
-			if f.Bytes() != nil {
-				return fieldName, f
+	case TypeList:
+		l := make([]interface{}, len(attr.L))
+		for i, a := range attr.L {
+			if a == nil {
+				continue
 			}
+			e, err := d.attributeInterface(a)
+			if err != nil {
+				return nil, decodeError(err, indexSegment(i), a, nil)
+			}
+			l[i] = e
+		}
+		return l, nil
 
-		case "BOOL", "N", "S", "NULL":
-			return fieldName, f
-
-		case "L", "M", "NS", "SS", "BS":
-			if f.Len() != 0 {
-				return fieldName, f
+	case TypeMap:
+		m := make(map[string]interface{}, len(attr.M))
+		for k, a := range attr.M {
+			if a == nil {
+				continue
 			}
+			e, err := d.attributeInterface(a)
+			if err != nil {
+				return nil, decodeError(err, k, a, nil)
+			}
+			m[k] = e
 		}
+		return m, nil
 	}
 
-	return "", zeroFieldVal
+	// NULL or an empty AttributeValue
+	return nil, nil
+}
+
+// number converts the value of an N attribute into a float64, or a
+// Number when the Decoder uses numbers
+func (d *Decoder) number(s string) (interface{}, error) {
+
+	if d.useNumber {
+		if !isValidNumber(s) {
+			return nil, ErrInvalidStringForNumber
+		}
+		return Number(s), nil
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, ErrInvalidStringForNumber
+	}
+	return n, nil
 }
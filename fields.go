@@ -0,0 +1,404 @@
+package marshalddb
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// field is a struct field that is read from and written to an attribute,
+// which may have been promoted from an embedded struct
+type field struct {
+	fieldTag
+	// goName is the name of the field in Go
+	goName string
+	// index is the sequence of field indexes leading to the field
+	// through any embedded structs
+	index []int
+	typ   reflect.Type
+
+	// encode and decode convert the field, chosen once for its type
+	encode encoderFunc
+	decode decoderFunc
+}
+
+// encoderFunc converts the value of a struct field, after createField has
+// followed any pointer to it
+type encoderFunc func(e *Encoder, f reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error)
+
+// decoderFunc sets a struct field from attr
+type decoderFunc func(d *Decoder, attr *dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error
+
+// structFields are the fields of a struct type, indexed by each of the
+// names an attribute is matched to them by
+type structFields struct {
+	list []field
+
+	byName     map[string]int
+	byGoName   map[string]int
+	byAlias    map[string]int
+	byFoldName map[string]int
+}
+
+// fieldCache holds the *structFields of each struct type seen so far, as
+// read from the struct tag tagKey and named by naming
+type fieldCache struct {
+	tagKey string
+	naming NamingStrategy
+	types  sync.Map
+}
+
+// defaultFields caches the fields of types converted by an Encoder or
+// Decoder without a tag key or naming strategy of its own
+var defaultFields = &fieldCache{tagKey: "dynamodb"}
+
+// fieldsFor returns the cache of fields read from tagKey and named by
+// naming, sharing defaultFields when neither is given
+func fieldsFor(tagKey string, naming NamingStrategy) *fieldCache {
+
+	if (tagKey == "" || tagKey == defaultFields.tagKey) && naming == nil {
+		return defaultFields
+	}
+	if tagKey == "" {
+		tagKey = defaultFields.tagKey
+	}
+
+	return &fieldCache{
+		tagKey: tagKey,
+		naming: naming,
+	}
+}
+
+// cachedTypeFields is like typeFields but only computes the fields of
+// each type once
+func (c *fieldCache) cachedTypeFields(t reflect.Type) *structFields {
+
+	if f, ok := c.types.Load(t); ok {
+		return f.(*structFields)
+	}
+
+	list := typeFields(t, c.tagKey, c.naming)
+	for i := range list {
+		list[i].encode = typeEncoder(list[i].typ)
+		list[i].decode = typeDecoder(t.FieldByIndex(list[i].index).Type)
+	}
+	fields := &structFields{
+		list:       list,
+		byName:     make(map[string]int, len(list)),
+		byGoName:   make(map[string]int, len(list)),
+		byAlias:    make(map[string]int),
+		byFoldName: make(map[string]int, len(list)),
+	}
+
+	// the first field in index order wins any name it shares with others
+	index := func(m map[string]int, name string, i int) {
+		if _, ok := m[name]; !ok {
+			m[name] = i
+		}
+	}
+	for i, f := range list {
+
+		index(fields.byName, f.name, i)
+		index(fields.byGoName, f.goName, i)
+		for _, a := range f.aliases {
+			index(fields.byAlias, a, i)
+		}
+		index(fields.byFoldName, strings.ToLower(f.name), i)
+	}
+
+	f, _ := c.types.LoadOrStore(t, fields)
+	return f.(*structFields)
+}
+
+// typeEncoder returns the encoderFunc of the type t, leaving any type that
+// is converted specially to createAttribute
+func typeEncoder(t reflect.Type) encoderFunc {
+
+	if isSpecial(t) || isMarshaled(t) {
+		return (*Encoder).createAttribute
+	}
+
+	switch t.Kind() {
+
+	case reflect.Ptr, reflect.Interface:
+		return (*Encoder).createAttribute
+
+	case reflect.String:
+		return (*Encoder).createString
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return (*Encoder).createNumeric
+
+	case reflect.Bool:
+		return (*Encoder).createBool
+	}
+
+	return (*Encoder).createValue
+}
+
+// typeDecoder returns the decoderFunc of the type t, leaving any type that
+// is converted specially to setAttribute
+func typeDecoder(t reflect.Type) decoderFunc {
+
+	if isSpecial(t) || isUnmarshaled(t) || t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return (*Decoder).setAttribute
+	}
+
+	return (*Decoder).setValue
+}
+
+// isSpecial reports whether t is converted by its type rather than its kind
+func isSpecial(t reflect.Type) bool {
+
+	return t == timeType || t == durationType || t == numberType || isBig(t)
+}
+
+// lookup finds the field an attribute name is matched to, preferring an
+// exact match on its attribute name, then on its Go name, then on one of
+// its aliases and finally on its attribute name without regard to case
+func (s *structFields) lookup(name string) (field, bool) {
+
+	for _, m := range []map[string]int{s.byName, s.byGoName, s.byAlias} {
+
+		if i, ok := m[name]; ok {
+			return s.list[i], true
+		}
+	}
+
+	if i, ok := s.byFoldName[strings.ToLower(name)]; ok {
+		return s.list[i], true
+	}
+
+	return field{}, false
+}
+
+// typeFields returns the fields of the struct type t, promoting the fields
+// of embedded structs by the same rules encoding/json uses: a field at a
+// shallower depth shadows deeper fields of the same name, a tagged field
+// wins over untagged fields at the same depth, and any other conflict
+// leaves no field by that name. Tags are read from the struct tag tagKey,
+// and untagged fields are named by naming if it is not nil.
+func typeFields(t reflect.Type, tagKey string, naming NamingStrategy) []field {
+
+	var (
+		current []field
+		next    = []field{{typ: t}}
+
+		// types of the embedded structs at the current and next depth
+		count     = map[reflect.Type]int{}
+		nextCount = map[reflect.Type]int{}
+
+		visited = map[reflect.Type]bool{}
+		fields  []field
+	)
+
+	for len(next) > 0 {
+
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+
+				sf := f.typ.Field(i)
+				isUnexported := sf.PkgPath != ""
+				if sf.Anonymous {
+
+					st := sf.Type
+					if st.Kind() == reflect.Ptr {
+						st = st.Elem()
+					}
+					// the exported fields of an unexported embedded
+					// struct are still promoted
+					if isUnexported && st.Kind() != reflect.Struct {
+						continue
+					}
+				} else if isUnexported {
+					continue
+				}
+
+				tag := parseFieldTag(sf, tagKey)
+				if tag.skip {
+					continue
+				}
+				if !tag.tagged && naming != nil {
+					tag.name = naming(sf.Name)
+				}
+
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// an untagged embedded struct has its fields promoted,
+				// anything else is a field of its own
+				if tag.tagged || !sf.Anonymous || ft.Kind() != reflect.Struct {
+
+					if isUnexported {
+						continue
+					}
+
+					fields = append(fields, field{
+						fieldTag: tag,
+						goName:   sf.Name,
+						index:    index,
+						typ:      ft,
+					})
+					if count[f.typ] > 1 {
+						// the same struct was embedded more than once
+						// at this depth, so its fields annihilate one
+						// another. Only one copy is needed to do so.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, field{
+						fieldTag: fieldTag{name: ft.Name()},
+						index:    index,
+						typ:      ft,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Sort(byName(fields))
+
+	// drop any fields hidden by the shadowing rules
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fi.name {
+				break
+			}
+		}
+
+		if advance == 1 {
+			out = append(out, fi)
+			continue
+		}
+		if dominant, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	fields = out
+	sort.Sort(byIndex(fields))
+
+	return fields
+}
+
+// dominantField looks through fields sharing a name, sorted by depth and
+// then by whether they are tagged, for the one that hides the rest
+func dominantField(fields []field) (field, bool) {
+
+	if len(fields) > 1 &&
+		len(fields[0].index) == len(fields[1].index) &&
+		fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+
+	return fields[0], true
+}
+
+// fieldByIndex returns the field of struct v found by following index,
+// or the zero Value if it is promoted through a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+
+	for i, x := range index {
+
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// allocFieldByIndex returns the field of struct v found by following
+// index, allocating any nil embedded pointers along the way. The zero
+// Value is returned if a pointer to an unexported struct can't be set.
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+
+	for i, x := range index {
+
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// byName sorts fields by name, breaking ties by depth, then by whether
+// they are tagged and then by index sequence
+type byName []field
+
+func (x byName) Len() int { return len(x) }
+
+func (x byName) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
+
+func (x byName) Less(i, j int) bool {
+
+	if x[i].name != x[j].name {
+		return x[i].name < x[j].name
+	}
+	if len(x[i].index) != len(x[j].index) {
+		return len(x[i].index) < len(x[j].index)
+	}
+	if x[i].tagged != x[j].tagged {
+		return x[i].tagged
+	}
+	return byIndex(x).Less(i, j)
+}
+
+// byIndex sorts fields by index sequence
+type byIndex []field
+
+func (x byIndex) Len() int { return len(x) }
+
+func (x byIndex) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
+
+func (x byIndex) Less(i, j int) bool {
+
+	for k, xik := range x[i].index {
+		if k >= len(x[j].index) {
+			return false
+		}
+		if xik != x[j].index[k] {
+			return xik < x[j].index[k]
+		}
+	}
+	return len(x[i].index) < len(x[j].index)
+}
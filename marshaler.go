@@ -0,0 +1,270 @@
+package marshalddb
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Marshaler is the interface implemented by types that can convert
+// themselves into an AttributeValue. Returning a nil AttributeValue
+// omits the value.
+type Marshaler interface {
+	MarshalDynamoDBAttributeValue() (*dynamodb.AttributeValue, error)
+}
+
+// Unmarshaler is the interface implemented by types that can set
+// themselves from an AttributeValue.
+type Unmarshaler interface {
+	UnmarshalDynamoDBAttributeValue(*dynamodb.AttributeValue) error
+}
+
+// Generated is implemented by types whose MarshalDynamoDBAttributeValue
+// and UnmarshalDynamoDBAttributeValue methods were written by
+// marshalddb-gen. Those methods convert the type as the package level
+// functions would, so an Encoder or Decoder with any options ignores them
+// and converts the type by reflection.
+type Generated interface {
+	MarshalDDBGenerated()
+}
+
+var (
+	generatedType         = reflect.TypeOf((*Generated)(nil)).Elem()
+	marshalerType         = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType       = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// createMarshaled converts v through the first of Marshaler,
+// encoding.TextMarshaler and encoding.BinaryMarshaler it implements.
+// TextMarshalers are stored as an S attribute and BinaryMarshalers as a B
+// attribute. ok is false if v implements none of them. Generated methods
+// are skipped if skipGenerated is set.
+func createMarshaled(v reflect.Value, skipGenerated bool) (fi *dynamodb.AttributeValue, ok bool, err error) {
+
+	if m, ok := valueAs(v, marshalerType); ok && !(skipGenerated && isGenerated(v.Type())) {
+		fi, err = m.(Marshaler).MarshalDynamoDBAttributeValue()
+		return fi, true, err
+	}
+
+	if m, ok := valueAs(v, textMarshalerType); ok {
+
+		text, err := m.(encoding.TextMarshaler).MarshalText()
+		if err != nil || len(text) == 0 {
+			return nil, true, err
+		}
+		return &dynamodb.AttributeValue{
+			S: aws.String(string(text)),
+		}, true, nil
+	}
+
+	if m, ok := valueAs(v, binaryMarshalerType); ok {
+
+		data, err := m.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil || len(data) == 0 {
+			return nil, true, err
+		}
+		return &dynamodb.AttributeValue{
+			B: data,
+		}, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// setUnmarshaled sets toField through the first of Unmarshaler,
+// encoding.TextUnmarshaler for an S attribute and
+// encoding.BinaryUnmarshaler for a B attribute that it implements.
+// ok is false if toField implements none of them. Generated methods are
+// skipped if skipGenerated is set.
+func setUnmarshaled(attr *dynamodb.AttributeValue, toField reflect.Value, skipGenerated bool) (ok bool, err error) {
+
+	if u, ok := indirectAs(toField, unmarshalerType); ok && !(skipGenerated && isGenerated(reflect.TypeOf(u))) {
+		return true, u.(Unmarshaler).UnmarshalDynamoDBAttributeValue(attr)
+	}
+
+	if attr.S != nil {
+		if u, ok := indirectAs(toField, textUnmarshalerType); ok {
+
+			text := *attr.S
+			err := u.(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+
+			// earlier versions stored TextMarshalers as JSON, quotes included,
+			// so retry with the quotes removed only if the raw text is rejected
+			var unquoted string
+			if err != nil && strings.HasPrefix(text, `"`) && json.Unmarshal([]byte(text), &unquoted) == nil {
+				if u.(encoding.TextUnmarshaler).UnmarshalText([]byte(unquoted)) == nil {
+					return true, nil
+				}
+			}
+			return true, err
+		}
+	}
+
+	if attr.B != nil {
+		if u, ok := indirectAs(toField, binaryUnmarshalerType); ok {
+			return true, u.(encoding.BinaryUnmarshaler).UnmarshalBinary(attr.B)
+		}
+	}
+
+	return false, nil
+}
+
+// isMarshaled reports whether t, or a pointer to t, implements any of
+// Marshaler, encoding.TextMarshaler or encoding.BinaryMarshaler
+func isMarshaled(t reflect.Type) bool {
+
+	for _, iface := range []reflect.Type{marshalerType, textMarshalerType, binaryMarshalerType} {
+
+		if t.Implements(iface) || (t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(iface)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isUnmarshaled reports whether a pointer to t implements any of
+// Unmarshaler, encoding.TextUnmarshaler or encoding.BinaryUnmarshaler
+func isUnmarshaled(t reflect.Type) bool {
+
+	for _, iface := range []reflect.Type{unmarshalerType, textUnmarshalerType, binaryUnmarshalerType} {
+
+		if reflect.PtrTo(t).Implements(iface) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isGenerated reports whether t, or a pointer to t, has methods written
+// by marshalddb-gen
+func isGenerated(t reflect.Type) bool {
+
+	return t.Implements(generatedType) || (t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(generatedType))
+}
+
+// valueAs returns v as the interface type iface. As with encoding/json,
+// methods with a pointer receiver are only used when v is addressable.
+func valueAs(v reflect.Value, iface reflect.Type) (interface{}, bool) {
+
+	if !v.IsValid() || v.Kind() == reflect.Interface || !v.CanInterface() {
+		return nil, false
+	}
+
+	if v.Type().Implements(iface) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+
+	if v.Kind() != reflect.Ptr && v.CanAddr() && v.Addr().Type().Implements(iface) {
+		return v.Addr().Interface(), true
+	}
+
+	return nil, false
+}
+
+// indirectAs walks down v, allocating nil pointers along the way,
+// until it finds a value that implements the interface type iface.
+func indirectAs(v reflect.Value, iface reflect.Type) (interface{}, bool) {
+
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+
+	if !pointsToImplementation(v.Type(), iface) || !v.CanInterface() {
+		return nil, false
+	}
+
+	for {
+
+		if v.IsNil() {
+			if !v.CanSet() {
+				return nil, false
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		if v.Type().Implements(iface) {
+			return v.Interface(), true
+		}
+		v = v.Elem()
+	}
+}
+
+// pointsToImplementation reports whether t, or any type t points to,
+// implements the interface type iface
+func pointsToImplementation(t, iface reflect.Type) bool {
+
+	for ; t.Kind() == reflect.Ptr; t = t.Elem() {
+		if t.Implements(iface) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A Field converts a single struct field by reflection, following the
+// default rules. It is used by code generated by marshalddb-gen, which
+// creates a Field for each struct field so that its tag is only parsed
+// once.
+type Field struct {
+	tag fieldTag
+}
+
+// NewField returns a Field for a struct field named name, tagged with the
+// options of a `dynamodb` tag such as "omitempty,string"
+func NewField(name, opts string) *Field {
+
+	f := &Field{tag: fieldTag{name: name}}
+	f.tag.setOptions(tagOptions(opts))
+	return f
+}
+
+// Marshal converts the value pointed to by v as the field. A nil
+// AttributeValue is returned if the field would be omitted.
+func (f *Field) Marshal(v interface{}) (*dynamodb.AttributeValue, error) {
+
+	from := reflect.ValueOf(v)
+	if from.Kind() != reflect.Ptr || from.IsNil() {
+		return nil, ErrNilTarget
+	}
+	from = from.Elem()
+
+	fi, err := defaultEncoder.createField(from, f.tag, (*Encoder).createAttribute)
+	if err != nil {
+		return nil, encodeError(err, f.tag.name, from.Type())
+	}
+	return fi, nil
+}
+
+// Unmarshal sets the value pointed to by v as the field, from av read
+// from the attribute named attr
+func (f *Field) Unmarshal(attr string, av *dynamodb.AttributeValue, v interface{}) error {
+
+	to := reflect.ValueOf(v)
+	if to.Kind() != reflect.Ptr || to.IsNil() {
+		return ErrNilTarget
+	}
+
+	if av == nil {
+		return nil
+	}
+
+	toEl := to.Elem()
+	if err := defaultDecoder.setAttribute(av, &toEl, f.tag); err != nil {
+		return decodeError(err, attr, av, toEl.Type())
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package marshalddb
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A NamingStrategy returns the attribute name of a struct field from its
+// Go name. It is only used for fields without a name in their struct tags.
+// Identity, LowerCamelCase, SnakeCase, KebabCase and ScreamingSnakeCase
+// are NamingStrategies, as is any other func with the same signature.
+type NamingStrategy func(goName string) string
+
+// Identity names an attribute by the field's Go name, such as "UserID"
+func Identity(goName string) string {
+
+	return goName
+}
+
+// LowerCamelCase names an attribute such as "userID" for the field UserID
+func LowerCamelCase(goName string) string {
+
+	words := splitWords(goName)
+	if len(words) == 0 {
+		return goName
+	}
+
+	words[0] = strings.ToLower(words[0])
+	return strings.Join(words, "")
+}
+
+// SnakeCase names an attribute such as "user_id" for the field UserID
+func SnakeCase(goName string) string {
+
+	return strings.ToLower(strings.Join(splitWords(goName), "_"))
+}
+
+// KebabCase names an attribute such as "user-id" for the field UserID
+func KebabCase(goName string) string {
+
+	return strings.ToLower(strings.Join(splitWords(goName), "-"))
+}
+
+// ScreamingSnakeCase names an attribute such as "USER_ID" for the field
+// UserID
+func ScreamingSnakeCase(goName string) string {
+
+	return strings.ToUpper(strings.Join(splitWords(goName), "_"))
+}
+
+// splitWords splits a Go name into its words, keeping acronyms such as
+// "HTTP" in "HTTPServer" whole and digits with the word before them.
+// Underscores also separate words.
+func splitWords(name string) []string {
+
+	var (
+		words []string
+		word  []rune
+	)
+
+	runes := []rune(name)
+	for i, r := range runes {
+
+		if r == '_' {
+			if len(word) != 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) != 0 {
+
+			prev := word[len(word)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			// a new word begins after a lower case letter or a digit, or
+			// with the last letter of an acronym followed by lower case
+			if !unicode.IsUpper(prev) || nextIsLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+
+		word = append(word, r)
+	}
+
+	if len(word) != 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
@@ -0,0 +1,234 @@
+package marshalddb
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Number is the string form of an N attribute, which keeps every digit of
+// precision that DynamoDB stores. It is decoded into interface{} values
+// by a Decoder created with UseNumber, and can be used as a field type
+// in the same way as json.Number.
+type Number string
+
+var numberType = reflect.TypeOf(Number(""))
+
+// The limits of an N attribute, which DynamoDB stores with up to 38
+// significant digits and a magnitude between 1E-130 and 1E+126
+const (
+	maxNumberDigits   = 38
+	minNumberExponent = -130
+	maxNumberExponent = 125
+)
+
+// String returns the literal text of the number
+func (n Number) String() string {
+	return string(n)
+}
+
+// Float64 returns the number as a float64
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// BigInt returns the number as a *big.Int, failing if it is not an integer
+func (n Number) BigInt() (*big.Int, error) {
+
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok || !r.IsInt() {
+		return nil, ErrInvalidStringForNumber
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// BigFloat returns the number as a *big.Float, with enough precision to
+// hold every digit DynamoDB stores
+func (n Number) BigFloat() (*big.Float, error) {
+
+	f, _, err := big.ParseFloat(string(n), 10, bigFloatPrec, big.ToNearestEven)
+	if err != nil {
+		return nil, ErrInvalidStringForNumber
+	}
+	return f, nil
+}
+
+// createNumber converts n into an N attribute, or an S attribute if the
+// field is tagged with the string option. The empty Number is omitted.
+func createNumber(n Number, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	if n == "" {
+		return nil, nil
+	}
+
+	if err := CheckNumber(string(n)); err != nil {
+		return nil, err
+	}
+
+	if tag.asString {
+		return &dynamodb.AttributeValue{
+			S: aws.String(string(n)),
+		}, nil
+	}
+
+	return &dynamodb.AttributeValue{
+		N: aws.String(string(n)),
+	}, nil
+}
+
+// isValidNumber reports whether s is a number DynamoDB would accept,
+// as an optionally signed decimal with an optional exponent
+func isValidNumber(s string) bool {
+
+	if s == "" {
+		return false
+	}
+
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+
+	digits := 0
+	for s != "" && '0' <= s[0] && s[0] <= '9' {
+		s = s[1:]
+		digits++
+	}
+
+	if s != "" && s[0] == '.' {
+		s = s[1:]
+		for s != "" && '0' <= s[0] && s[0] <= '9' {
+			s = s[1:]
+			digits++
+		}
+	}
+
+	if digits == 0 {
+		return false
+	}
+
+	if s != "" && (s[0] == 'e' || s[0] == 'E') {
+
+		s = s[1:]
+		if s != "" && (s[0] == '-' || s[0] == '+') {
+			s = s[1:]
+		}
+		if s == "" {
+			return false
+		}
+		for s != "" && '0' <= s[0] && s[0] <= '9' {
+			s = s[1:]
+		}
+	}
+
+	return s == ""
+}
+
+// CheckNumber returns an error if s cannot be stored as an N attribute:
+// ErrInvalidStringForNumber if it is not a number and ErrNumberRange if
+// DynamoDB cannot store it.
+func CheckNumber(s string) error {
+
+	if !isValidNumber(s) {
+		return ErrInvalidStringForNumber
+	}
+	if !inNumberRange(s) {
+		return ErrNumberRange
+	}
+	return nil
+}
+
+// FormatFloat formats f as DynamoDB expects of an N attribute, rounded to
+// a float32 if bitSize is 32. ErrInvalidFloat is returned for infinities
+// and NaN, and ErrNumberRange for numbers DynamoDB cannot store.
+func FormatFloat(f float64, bitSize int) (string, error) {
+
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", ErrInvalidFloat
+	}
+	n := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !inNumberRange(n) {
+		return "", ErrNumberRange
+	}
+	return n, nil
+}
+
+// inNumberRange reports whether the valid number s is within the
+// precision and magnitude DynamoDB can store
+func inNumberRange(s string) bool {
+
+	_, digits, exp, ok := splitNumber(s)
+	if !ok {
+		return false
+	}
+	if digits == "" {
+		// zero
+		return true
+	}
+
+	if len(digits) > maxNumberDigits {
+		return false
+	}
+
+	// the exponent of the number in scientific notation
+	exp += len(digits) - 1
+	return exp >= minNumberExponent && exp <= maxNumberExponent
+}
+
+// canonicalNumber returns the valid number s in a form shared by every
+// spelling of the same value, such as 1, 1.0 and 1e0
+func canonicalNumber(s string) string {
+
+	neg, digits, exp, ok := splitNumber(s)
+	if !ok {
+		return s
+	}
+	if digits == "" {
+		return "0"
+	}
+
+	sign := ""
+	if neg {
+		sign = "-"
+	}
+	return sign + digits + "e" + strconv.Itoa(exp)
+}
+
+// splitNumber splits the valid number s into its sign and the integer
+// digits, without leading or trailing zeros, scaled by 10^exp. digits is
+// empty if s is zero.
+func splitNumber(s string) (neg bool, digits string, exp int, ok bool) {
+
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return false, "", 0, false
+		}
+		s, exp = s[:i], e
+	}
+
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exp -= len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+
+	s = strings.TrimLeft(s, "0")
+	digits = strings.TrimRight(s, "0")
+	exp += len(s) - len(digits)
+	return neg, digits, exp, true
+}
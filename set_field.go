@@ -1,36 +1,40 @@
 package marshalddb
 
 import (
+	"encoding"
 	"encoding/json"
 	"reflect"
+	"sort"
 	"strconv"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
-func setFieldWithKind(kind reflect.Kind, fromField reflect.Value, toField *reflect.Value) error {
+func setFieldWithKind(kind reflect.Kind, from string, toField *reflect.Value) error {
 
 	var err error
 	switch kind {
 
 	case reflect.String:
-		toField.SetString(fromField.String())
+		toField.SetString(from)
 
 	case reflect.Bool:
-		err = setBool(fromField, toField)
+		err = setBool(from, toField)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		err = setInt(fromField, toField)
+		err = setInt(from, toField)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		err = setUint(fromField, toField)
+		err = setUint(from, toField)
 
 	case reflect.Float32, reflect.Float64:
-		err = setFloat(fromField, toField)
+		err = setFloat(from, toField)
 
 	case reflect.Slice:
 		err = ErrConversionNotSupported
 
 	case reflect.Array, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Struct:
-		err = setJSON(fromField, toField)
+		err = setJSON(from, toField)
 
 	default:
 		err = ErrConversionNotSupported
@@ -40,10 +44,15 @@ func setFieldWithKind(kind reflect.Kind, fromField reflect.Value, toField *refle
 	return err
 }
 
-func setBool(fieldEl reflect.Value, toField *reflect.Value) error {
+func setBool(from string, toField *reflect.Value) error {
+
+	// bools stored with the string option
+	if b, err := strconv.ParseBool(from); err == nil {
+		toField.SetBool(b)
+		return nil
+	}
 
-	fromVal := fieldEl.String()
-	n, err := strconv.ParseInt(fromVal, 10, 64)
+	n, err := strconv.ParseInt(from, 10, 64)
 	if err != nil {
 		return ErrInvalidStringForNumber
 	}
@@ -51,10 +60,9 @@ func setBool(fieldEl reflect.Value, toField *reflect.Value) error {
 	return nil
 }
 
-func setInt(fieldEl reflect.Value, toField *reflect.Value) error {
+func setInt(from string, toField *reflect.Value) error {
 
-	fromVal := fieldEl.String()
-	n, err := strconv.ParseInt(fromVal, 10, 64)
+	n, err := strconv.ParseInt(from, 10, 64)
 	if err != nil {
 		return ErrInvalidStringForNumber
 	}
@@ -65,43 +73,12 @@ func setInt(fieldEl reflect.Value, toField *reflect.Value) error {
 	return nil
 }
 
-func setUint(fieldEl reflect.Value, toField *reflect.Value) error {
-
-	var (
-		n   uint64
-		err error
-	)
-
-	switch fieldEl.Kind() {
-
-	case reflect.String:
-		fromVal := fieldEl.String()
-		n, err = strconv.ParseUint(fromVal, 10, 64)
-
-	case reflect.Bool:
-		if fieldEl.Bool() {
-			n = 1
-		} else {
-			n = 0
-		}
-
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		n = uint64(fieldEl.Int())
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		n = fieldEl.Uint()
-
-	case reflect.Float32, reflect.Float64:
-		n = uint64(fieldEl.Float())
-
-	default:
-		err = ErrConversionNotSupported
-	}
+func setUint(from string, toField *reflect.Value) error {
 
+	n, err := strconv.ParseUint(from, 10, 64)
 	if err != nil {
 		return ErrConversionNotSupported
 	}
-
 	if toField.OverflowUint(n) {
 		return ErrNumericOverflow
 	}
@@ -109,10 +86,9 @@ func setUint(fieldEl reflect.Value, toField *reflect.Value) error {
 	return nil
 }
 
-func setFloat(fieldEl reflect.Value, toField *reflect.Value) error {
+func setFloat(from string, toField *reflect.Value) error {
 
-	fromVal := fieldEl.String()
-	n, err := strconv.ParseFloat(fromVal, toField.Type().Bits())
+	n, err := strconv.ParseFloat(from, toField.Type().Bits())
 	if err != nil {
 		return ErrInvalidStringForNumber
 	}
@@ -123,16 +99,175 @@ func setFloat(fieldEl reflect.Value, toField *reflect.Value) error {
 	return nil
 }
 
-func setJSON(fieldEl reflect.Value, toField *reflect.Value) error {
+func setJSON(from string, toField *reflect.Value) error {
 
-	fromVal := fieldEl.String()
 	// create a new instance of the target
 	newTarget := reflect.New(toField.Type())
 	// unmarshal our AttributeValue's value into our new target
-	if err := json.Unmarshal([]byte(fromVal), newTarget.Interface()); err != nil {
+	if err := json.Unmarshal([]byte(from), newTarget.Interface()); err != nil {
 		return ErrInvalidJSON
 	}
 	// set our target field with the unmarshaled result
 	toField.Set(newTarget.Elem())
 	return nil
 }
+
+// setMap sets the contents of an M attribute onto a struct or a map keyed by
+// strings
+func (d *Decoder) setMap(item map[string]*dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error {
+
+	var errs Errors
+	switch toField.Kind() {
+
+	case reflect.Struct:
+		return d.setStructFields(item, *toField)
+
+	case reflect.Map:
+
+		mt := toField.Type()
+		if mt.Key().Kind() != reflect.String && !reflect.PtrTo(mt.Key()).Implements(textUnmarshalerType) {
+			return ErrConversionNotSupported
+		}
+
+		if toField.IsNil() {
+			toField.Set(reflect.MakeMap(mt))
+		}
+
+		for _, key := range d.itemKeys(item) {
+
+			attrValue := item[key]
+			if attrValue == nil {
+				continue
+			}
+
+			elem := reflect.New(mt.Elem()).Elem()
+			if err := d.setAttribute(attrValue, &elem, tag.elem()); err != nil {
+				err = decodeError(err, key, attrValue, mt.Elem())
+				if !d.collectErrors {
+					return err
+				}
+				errs = errs.add(err)
+				continue
+			}
+
+			k := reflect.New(mt.Key())
+			if u, ok := k.Interface().(encoding.TextUnmarshaler); ok && mt.Key().Kind() != reflect.String {
+				if err := u.UnmarshalText([]byte(key)); err != nil {
+					err = decodeError(err, key, nil, mt.Key())
+					if !d.collectErrors {
+						return err
+					}
+					errs = errs.add(err)
+					continue
+				}
+			} else {
+				k.Elem().SetString(key)
+			}
+			toField.SetMapIndex(k.Elem(), elem)
+		}
+
+	default:
+		return ErrInvalidConversion
+	}
+
+	return errs.err()
+}
+
+// setList sets each element of an L attribute onto a slice or an array
+func (d *Decoder) setList(list []*dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error {
+
+	var errs Errors
+	switch toField.Kind() {
+
+	case reflect.Slice:
+
+		arr := reflect.MakeSlice(toField.Type(), len(list), len(list))
+		for i, attrValue := range list {
+
+			if attrValue == nil {
+				continue
+			}
+
+			toFieldAtIndex := arr.Index(i)
+			if err := d.setAttribute(attrValue, &toFieldAtIndex, tag.elem()); err != nil {
+				err = decodeError(err, indexSegment(i), attrValue, toFieldAtIndex.Type())
+				if !d.collectErrors {
+					return err
+				}
+				errs = errs.add(err)
+			}
+		}
+		toField.Set(arr)
+
+	case reflect.Array:
+
+		for i := 0; i < toField.Len(); i++ {
+
+			toFieldAtIndex := toField.Index(i)
+			if i >= len(list) || list[i] == nil {
+				// zero any remaining elements, as encoding/json does
+				toFieldAtIndex.Set(reflect.Zero(toFieldAtIndex.Type()))
+				continue
+			}
+
+			if err := d.setAttribute(list[i], &toFieldAtIndex, tag.elem()); err != nil {
+				err = decodeError(err, indexSegment(i), list[i], toFieldAtIndex.Type())
+				if !d.collectErrors {
+					return err
+				}
+				errs = errs.add(err)
+			}
+		}
+
+	default:
+		return ErrInvalidConversion
+	}
+
+	return errs.err()
+}
+
+// setMembers splits the members of an SS, NS or BS attribute into
+// their own S, N or B attributes
+func setMembers(attr *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
+
+	var list []*dynamodb.AttributeValue
+	switch AttributeType(attr) {
+
+	case TypeStringSet:
+		for _, s := range attr.SS {
+			list = append(list, &dynamodb.AttributeValue{
+				S: s,
+			})
+		}
+
+	case TypeNumberSet:
+		for _, n := range attr.NS {
+			list = append(list, &dynamodb.AttributeValue{
+				N: n,
+			})
+		}
+
+	case TypeBinarySet:
+		for _, b := range attr.BS {
+			list = append(list, &dynamodb.AttributeValue{
+				B: b,
+			})
+		}
+	}
+
+	return list
+}
+
+// itemKeys returns the attribute names of item, sorted when errors are
+// collected so that they are met in the same order on every run
+func (d *Decoder) itemKeys(item map[string]*dynamodb.AttributeValue) []string {
+
+	keys := make([]string, 0, len(item))
+	for k := range item {
+		keys = append(keys, k)
+	}
+	if d.collectErrors {
+		sort.Strings(keys)
+	}
+	return keys
+}
@@ -0,0 +1,278 @@
+package marshalddb
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// StringSet is a set of strings, stored as an SS attribute
+type StringSet map[string]struct{}
+
+// NumberSet is a set of numbers, stored as an NS attribute
+type NumberSet map[Number]struct{}
+
+// BinarySet is a set of byte slices, stored as a BS attribute. Its members
+// are kept as strings since byte slices cannot be map keys.
+type BinarySet map[string]struct{}
+
+var binarySetType = reflect.TypeOf(BinarySet(nil))
+
+// NewStringSet returns a StringSet holding members
+func NewStringSet(members ...string) StringSet {
+
+	s := make(StringSet, len(members))
+	for _, m := range members {
+		s.Add(m)
+	}
+	return s
+}
+
+// Add adds v to the set
+func (s StringSet) Add(v string) {
+	s[v] = struct{}{}
+}
+
+// Remove removes v from the set
+func (s StringSet) Remove(v string) {
+	delete(s, v)
+}
+
+// Contains reports whether v is a member of the set
+func (s StringSet) Contains(v string) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// NewNumberSet returns a NumberSet holding members
+func NewNumberSet(members ...Number) NumberSet {
+
+	s := make(NumberSet, len(members))
+	for _, m := range members {
+		s.Add(m)
+	}
+	return s
+}
+
+// Add adds n to the set
+func (s NumberSet) Add(n Number) {
+	s[n] = struct{}{}
+}
+
+// Remove removes n from the set
+func (s NumberSet) Remove(n Number) {
+	delete(s, n)
+}
+
+// Contains reports whether n is a member of the set
+func (s NumberSet) Contains(n Number) bool {
+	_, ok := s[n]
+	return ok
+}
+
+// NewBinarySet returns a BinarySet holding members
+func NewBinarySet(members ...[]byte) BinarySet {
+
+	s := make(BinarySet, len(members))
+	for _, m := range members {
+		s.Add(m)
+	}
+	return s
+}
+
+// Add adds b to the set
+func (s BinarySet) Add(b []byte) {
+	s[string(b)] = struct{}{}
+}
+
+// Remove removes b from the set
+func (s BinarySet) Remove(b []byte) {
+	delete(s, string(b))
+}
+
+// Contains reports whether b is a member of the set
+func (s BinarySet) Contains(b []byte) bool {
+	_, ok := s[string(b)]
+	return ok
+}
+
+// isMapSet reports whether a map of type t is stored as a set, which is
+// the case for a map[T]struct{}, and for a map[T]bool tagged as a set
+func isMapSet(t reflect.Type, tag fieldTag) bool {
+
+	if t.Kind() != reflect.Map || !isSetElem(t.Key()) {
+		return false
+	}
+
+	switch et := t.Elem(); et.Kind() {
+
+	case reflect.Struct:
+		return et.NumField() == 0
+
+	case reflect.Bool:
+		return tag.asSet
+	}
+
+	return false
+}
+
+// createMapSet converts the keys of a map set into an SS, NS or BS
+// attribute. The keys of a map[T]bool are only members while true.
+func createMapSet(from reflect.Value, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	members := reflect.MakeSlice(reflect.SliceOf(from.Type().Key()), 0, from.Len())
+	for _, k := range from.MapKeys() {
+
+		if v := from.MapIndex(k); v.Kind() == reflect.Bool && !v.Bool() {
+			continue
+		}
+		members = reflect.Append(members, k)
+	}
+
+	fi, err := createSet(members, tag.binary || from.Type() == binarySetType)
+	if fi != nil {
+		// maps are unordered, so sort the members to keep the output stable
+		sortMembers(fi)
+	}
+	return fi, err
+}
+
+// createSet converts a slice into an SS, NS or BS attribute, failing if
+// it holds duplicate members. Empty sets are omitted.
+func createSet(from reflect.Value, binary bool) (*dynamodb.AttributeValue, error) {
+
+	if from.Len() == 0 {
+		return nil, nil
+	}
+
+	var (
+		fi  *dynamodb.AttributeValue
+		err error
+	)
+
+	et := from.Type().Elem()
+	switch {
+
+	case binary:
+		fi, err = createBinary(from)
+
+	case et.Kind() == reflect.String && et != numberType:
+		fi = createSS(from)
+
+	case et.Kind() == reflect.Slice || et.Kind() == reflect.Array:
+		fi = createBS(from)
+
+	default:
+		fi, err = createNS(from)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if hasDuplicateMembers(fi) {
+		return nil, ErrDuplicateSetMember
+	}
+
+	return fi, nil
+}
+
+// hasDuplicateMembers reports whether an SS, NS or BS attribute holds
+// the same member more than once, which DynamoDB rejects
+func hasDuplicateMembers(set *dynamodb.AttributeValue) bool {
+
+	seen := make(map[string]bool)
+	for _, m := range setStrings(set) {
+
+		// DynamoDB compares numbers by value, not by how they are written
+		if set.NS != nil {
+			m = canonicalNumber(m)
+		}
+		if seen[m] {
+			return true
+		}
+		seen[m] = true
+	}
+
+	return false
+}
+
+// sortMembers sorts the members of an SS, NS or BS attribute
+func sortMembers(set *dynamodb.AttributeValue) {
+
+	switch {
+
+	case set.SS != nil:
+		sort.Slice(set.SS, func(i, j int) bool { return *set.SS[i] < *set.SS[j] })
+
+	case set.NS != nil:
+		sort.Slice(set.NS, func(i, j int) bool { return *set.NS[i] < *set.NS[j] })
+
+	case set.BS != nil:
+		sort.Slice(set.BS, func(i, j int) bool { return string(set.BS[i]) < string(set.BS[j]) })
+	}
+}
+
+// setStrings returns the members of an SS, NS or BS attribute as strings
+func setStrings(set *dynamodb.AttributeValue) []string {
+
+	var members []string
+	for _, s := range set.SS {
+		members = append(members, *s)
+	}
+	for _, n := range set.NS {
+		members = append(members, *n)
+	}
+	for _, b := range set.BS {
+		members = append(members, string(b))
+	}
+
+	return members
+}
+
+// setMapSet adds each member of a set onto a map set
+func (d *Decoder) setMapSet(members []*dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error {
+
+	mt := toField.Type()
+
+	var present reflect.Value
+	switch mt.Elem().Kind() {
+
+	case reflect.Bool:
+		present = reflect.ValueOf(true).Convert(mt.Elem())
+
+	case reflect.Struct:
+		if mt.Elem().NumField() != 0 {
+			return ErrInvalidConversion
+		}
+		present = reflect.Zero(mt.Elem())
+
+	default:
+		return ErrInvalidConversion
+	}
+
+	if toField.IsNil() {
+		toField.Set(reflect.MakeMap(mt))
+	}
+
+	// BinarySet keys hold the bytes of B members
+	elem := tag.elem()
+	elem.binary = elem.binary || mt == binarySetType
+
+	var errs Errors
+	for i, m := range members {
+
+		k := reflect.New(mt.Key()).Elem()
+		if err := d.setAttribute(m, &k, elem); err != nil {
+			err = decodeError(err, indexSegment(i), m, mt.Key())
+			if !d.collectErrors {
+				return err
+			}
+			errs = errs.add(err)
+			continue
+		}
+		toField.SetMapIndex(k, present)
+	}
+
+	return errs.err()
+}
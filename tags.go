@@ -0,0 +1,185 @@
+package marshalddb
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// fieldTag describes how a struct field is named and encoded, as given
+// by its `dynamodb` tag:
+//
+//	Field int `dynamodb:"name,opts..."`
+//
+// The supported options are:
+//
+//	omitempty  do not write the field if it holds an empty value
+//	nullable   write a NULL attribute for a nil pointer, or any other
+//	           value DynamoDB cannot store, rather than omitting it
+//	string     store a number or bool as an S attribute
+//	set        store a slice as an SS, NS or BS attribute, failing if it
+//	           holds duplicate members, or a map[T]bool as a set of its
+//	           true keys
+//	list       store a slice as an L attribute, keeping its order
+//	binary     store a string or byte slice as a B attribute, and a
+//	           slice of them as a BS attribute
+//	json       store a struct or map as a JSON encoded S attribute
+//	unix       store a time.Time as an N attribute of Unix seconds
+//	unixmilli  store a time.Time as an N attribute of Unix milliseconds
+//	unixnano   store a time.Time as an N attribute of Unix nanoseconds,
+//	           failing with ErrNumberRange outside the years 1678 to 2262
+//	required   fail decoding an item without the attribute, when the
+//	           Decoder enforces required fields
+//	alias=old  also decode the field from the attribute named old, which
+//	           may be given more than once
+//
+// A time.Time is otherwise stored as an RFC3339 S attribute, and a
+// time.Duration as an N attribute of nanoseconds, or as an S attribute
+// such as "1h30m" when tagged with the string option. The time options
+// and binary options also apply to the elements of a tagged slice or map.
+//
+// A field tagged `dynamodb:"-"` is skipped, while `dynamodb:"-,"` names
+// the attribute "-". Fields without a `dynamodb` tag are read from their
+// `json` tag by the rules of encoding/json. Fields with a `dynamodb` tag
+// but without a name in it are still named by their `json` tag.
+type fieldTag struct {
+	name string
+	// tagged is true if name was given by a struct tag
+	tagged    bool
+	skip      bool
+	omitEmpty bool
+	nullable  bool
+	required  bool
+	asString  bool
+	asSet     bool
+	asList    bool
+	binary    bool
+	json      bool
+	// aliases are the other attribute names the field is decoded from
+	aliases []string
+	// unixTime is the unit of a time.Time stored as a number,
+	// or zero if it is stored as a string
+	unixTime time.Duration
+}
+
+// parseFieldTag reads the tagKey and `json` tags of a struct field, where
+// tagKey is "dynamodb" unless an Encoder or Decoder was given another
+func parseFieldTag(sf reflect.StructField, tagKey string) fieldTag {
+
+	tag := fieldTag{
+		name: sf.Name,
+	}
+
+	ddb, hasDDB := sf.Tag.Lookup(tagKey)
+	js := sf.Tag.Get("json")
+
+	var (
+		name string
+		opts tagOptions
+	)
+
+	switch {
+
+	case ddb == "-" || (!hasDDB && js == "-"):
+		tag.skip = true
+		return tag
+
+	case hasDDB:
+		name, opts = parseTag(ddb)
+		if name == "" && js != "-" {
+			name, _ = parseTag(js)
+		}
+
+	default:
+		name, opts = parseTag(js)
+	}
+
+	if name != "" {
+		tag.name = name
+		tag.tagged = true
+	}
+
+	tag.setOptions(opts)
+	return tag
+}
+
+// setOptions sets the options of a `dynamodb` or `json` tag onto t
+func (t *fieldTag) setOptions(opts tagOptions) {
+
+	t.omitEmpty = opts.Contains("omitempty")
+	t.nullable = opts.Contains("nullable")
+	t.required = opts.Contains("required")
+	t.asString = opts.Contains("string")
+	t.asSet = opts.Contains("set")
+	t.asList = opts.Contains("list")
+	t.binary = opts.Contains("binary")
+	t.json = opts.Contains("json")
+	t.aliases = opts.Values("alias")
+
+	switch {
+
+	case opts.Contains("unix"):
+		t.unixTime = time.Second
+
+	case opts.Contains("unixmilli"):
+		t.unixTime = time.Millisecond
+
+	case opts.Contains("unixnano"):
+		t.unixTime = time.Nanosecond
+	}
+}
+
+// elem returns the options that apply to the elements of a slice or map
+func (t fieldTag) elem() fieldTag {
+
+	return fieldTag{
+		binary:   t.binary,
+		unixTime: t.unixTime,
+	}
+}
+
+// tagOptions is the string following a comma in a struct field's
+// `dynamodb` or `json` tag, or the empty string.
+type tagOptions string
+
+// parseTag splits a struct field's tag into its name and
+// comma-separated options.
+func parseTag(tag string) (string, tagOptions) {
+
+	if idx := strings.Index(tag, ","); idx != -1 {
+		return tag[:idx], tagOptions(tag[idx+1:])
+	}
+	return tag, tagOptions("")
+}
+
+// Contains reports whether a comma-separated list of options
+// contains a particular optionName flag.
+func (o tagOptions) Contains(optionName string) bool {
+
+	s := string(o)
+	for s != "" {
+
+		var next string
+		if i := strings.Index(s, ","); i >= 0 {
+			s, next = s[:i], s[i+1:]
+		}
+		if s == optionName {
+			return true
+		}
+		s = next
+	}
+	return false
+}
+
+// Values returns the values of each key=value option with the given key
+func (o tagOptions) Values(key string) []string {
+
+	var values []string
+	for _, opt := range strings.Split(string(o), ",") {
+
+		if strings.HasPrefix(opt, key+"=") {
+			values = append(values, opt[len(key)+1:])
+		}
+	}
+	return values
+}
@@ -0,0 +1,137 @@
+package marshalddb
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// createTime converts t into an RFC3339 S attribute, or an N attribute
+// of the Unix time in the unit given by the field's tag. Unix nanoseconds
+// only fit an int64 for times between the years 1678 and 2262.
+func createTime(t time.Time, tag fieldTag) (*dynamodb.AttributeValue, error) {
+
+	if tag.unixTime == 0 {
+		return &dynamodb.AttributeValue{
+			S: aws.String(t.Format(time.RFC3339Nano)),
+		}, nil
+	}
+
+	var n int64
+	switch tag.unixTime {
+
+	case time.Second:
+		n = t.Unix()
+
+	case time.Millisecond:
+		n = t.Unix()*1e3 + int64(t.Nanosecond())/1e6
+
+	default:
+		n = t.UnixNano()
+		if !time.Unix(0, n).Equal(t) {
+			return nil, ErrNumberRange
+		}
+	}
+
+	return &dynamodb.AttributeValue{
+		N: aws.String(strconv.FormatInt(n, 10)),
+	}, nil
+}
+
+// createDuration converts d into an N attribute of nanoseconds, or an S
+// attribute such as "1h30m" if the field is tagged with the string option
+func createDuration(d time.Duration, tag fieldTag) *dynamodb.AttributeValue {
+
+	if tag.asString {
+		return &dynamodb.AttributeValue{
+			S: aws.String(d.String()),
+		}
+	}
+
+	return &dynamodb.AttributeValue{
+		N: aws.String(strconv.FormatInt(int64(d), 10)),
+	}
+}
+
+// setTime sets a time.Time from either an RFC3339 S attribute or an N
+// attribute of the Unix time. The unit of an N attribute is given by the
+// field's tag, defaulting to seconds.
+func setTime(attr *dynamodb.AttributeValue, toField *reflect.Value, tag fieldTag) error {
+
+	var t time.Time
+	switch {
+
+	case attr.S != nil:
+		var err error
+		if t, err = time.Parse(time.RFC3339Nano, *attr.S); err != nil {
+			// earlier versions stored times as JSON, quotes included
+			if json.Unmarshal([]byte(*attr.S), &t) != nil {
+				return ErrInvalidConversion
+			}
+		}
+
+	case attr.N != nil:
+		n, err := strconv.ParseInt(*attr.N, 10, 64)
+		if err != nil {
+			return ErrInvalidStringForNumber
+		}
+
+		unit := tag.unixTime
+		if unit == 0 {
+			unit = time.Second
+		}
+		perSecond := int64(time.Second / unit)
+		t = time.Unix(n/perSecond, n%perSecond*int64(unit)).UTC()
+
+	case attr.NULL != nil:
+		// leave the zero time
+
+	default:
+		return ErrInvalidConversion
+	}
+
+	toField.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// setDuration sets a time.Duration from either an N attribute of
+// nanoseconds or an S attribute such as "1h30m"
+func setDuration(attr *dynamodb.AttributeValue, toField *reflect.Value) error {
+
+	var s string
+	switch {
+
+	case attr.N != nil:
+		s = *attr.N
+
+	case attr.S != nil:
+		s = *attr.S
+		if d, err := time.ParseDuration(s); err == nil {
+			toField.SetInt(int64(d))
+			return nil
+		}
+
+	case attr.NULL != nil:
+		toField.SetInt(0)
+		return nil
+
+	default:
+		return ErrInvalidConversion
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return ErrInvalidStringForNumber
+	}
+	toField.SetInt(n)
+	return nil
+}